@@ -0,0 +1,106 @@
+// Schema validation playground. Loads the revisor WebAssembly module with the
+// constraint sets of the schema version and the link index. The module links
+// the validation errors to the schema pages.
+
+(function () {
+  'use strict';
+
+  var root = document.getElementById('schema-playground');
+  if (!root) return;
+
+  var basePath = root.dataset.basePath || '';
+  var input = document.getElementById('playground-input');
+  var button = document.getElementById('playground-validate');
+  var examples = document.getElementById('playground-example');
+  var status = document.getElementById('playground-status');
+  var results = document.getElementById('playground-results');
+  var exampleDocs = {};
+
+  function setStatus(text, isError) {
+    status.textContent = text;
+    status.classList.toggle('error', !!isError);
+  }
+
+  function fetchText(url) {
+    return fetch(url).then(function (res) {
+      if (!res.ok) throw new Error('fetch ' + url + ': ' + res.status);
+      return res.text();
+    });
+  }
+
+  function showResults(res) {
+    results.innerHTML = '';
+
+    if (res.error) {
+      setStatus(res.error, true);
+      return;
+    }
+
+    if (res.problems.length === 0) {
+      setStatus('The document is valid.');
+      return;
+    }
+
+    setStatus(res.problems.length === 1 ? '1 problem' : res.problems.length + ' problems', true);
+
+    res.problems.forEach(function (p) {
+      var li = document.createElement('li');
+      li.appendChild(document.createTextNode(p.message + ' '));
+
+      if (p.href) {
+        var a = document.createElement('a');
+        a.href = basePath + p.href;
+        a.textContent = 'Documentation';
+        li.appendChild(a);
+      }
+
+      results.appendChild(li);
+    });
+  }
+
+  function validate() {
+    showResults(JSON.parse(window.revisorPlayground.validate(input.value)));
+  }
+
+  function addExamples() {
+    Object.keys(exampleDocs).sort().forEach(function (type) {
+      var opt = document.createElement('option');
+      opt.value = type;
+      opt.textContent = type;
+      examples.appendChild(opt);
+    });
+
+    examples.addEventListener('change', function () {
+      var doc = exampleDocs[examples.value];
+      if (!doc) return;
+
+      input.value = JSON.stringify(doc, null, 2);
+      results.innerHTML = '';
+      setStatus('');
+    });
+  }
+
+  var go = new Go();
+
+  Promise.all([
+    WebAssembly.instantiateStreaming(fetch(root.dataset.wasm), go.importObject),
+    fetchText(root.dataset.constraints),
+    fetchText(root.dataset.links),
+    fetchText(root.dataset.examples),
+  ]).then(function (loaded) {
+    go.run(loaded[0].instance);
+
+    var err = window.revisorPlayground.load(loaded[1], loaded[2]);
+    if (err) throw new Error(err);
+
+    exampleDocs = JSON.parse(loaded[3]);
+
+    addExamples();
+
+    button.disabled = false;
+    button.addEventListener('click', validate);
+    setStatus('Paste a document and validate it.');
+  }).catch(function (err) {
+    setStatus('Failed to load the validator: ' + err.message, true);
+  });
+})();
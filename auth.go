@@ -0,0 +1,145 @@
+package elephantdocs
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-git/go-git/v6/plumbing/transport"
+	"github.com/go-git/go-git/v6/plumbing/transport/http"
+	"github.com/go-git/go-git/v6/plumbing/transport/ssh"
+	"github.com/ttab/elephant-docs/internal"
+)
+
+const defaultAuthUser = "git"
+
+// cloneAuth creates the auth method to use when cloning from cloneURL. Returns
+// nil if no authentication has been configured. Errors never include the
+// secrets themselves, only the names of the variables or files that they were
+// read from.
+func cloneAuth(cloneURL string, conf *AuthConfig) (transport.AuthMethod, error) {
+	if conf == nil {
+		return nil, nil
+	}
+
+	ep, err := transport.NewEndpoint(cloneURL)
+	if err != nil {
+		return nil, fmt.Errorf("parse clone URL: %w", err)
+	}
+
+	username := conf.Username
+	if username == "" {
+		username = ep.User
+	}
+
+	if username == "" {
+		username = defaultAuthUser
+	}
+
+	switch ep.Protocol {
+	case "ssh":
+		return sshAuth(username, conf)
+	case "http", "https":
+		return httpAuth(ep.Host, username, conf)
+	default:
+		return nil, nil
+	}
+}
+
+func sshAuth(username string, conf *AuthConfig) (transport.AuthMethod, error) {
+	if conf.SSHKey == "" {
+		auth, err := ssh.NewSSHAgentAuth(username)
+		if err != nil {
+			return nil, fmt.Errorf("use ssh agent: %w", err)
+		}
+
+		return auth, nil
+	}
+
+	keyPath, err := expandHome(conf.SSHKey)
+	if err != nil {
+		return nil, err
+	}
+
+	var passphrase string
+
+	if conf.SSHKeyPassphraseEnv != "" {
+		passphrase, err = secretFromEnv(conf.SSHKeyPassphraseEnv)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	auth, err := ssh.NewPublicKeysFromFile(username, keyPath, passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("load ssh key %q: %w", conf.SSHKey, err)
+	}
+
+	return auth, nil
+}
+
+func httpAuth(host string, username string, conf *AuthConfig) (transport.AuthMethod, error) {
+	if conf.TokenEnv != "" {
+		token, err := secretFromEnv(conf.TokenEnv)
+		if err != nil {
+			return nil, err
+		}
+
+		return &http.BasicAuth{
+			Username: username,
+			Password: token,
+		}, nil
+	}
+
+	if conf.Netrc != "" {
+		netrcPath, err := expandHome(conf.Netrc)
+		if err != nil {
+			return nil, err
+		}
+
+		entry, ok, err := internal.NetrcLookup(netrcPath, host)
+		if err != nil {
+			return nil, fmt.Errorf("read netrc %q: %w", conf.Netrc, err)
+		}
+
+		if !ok {
+			return nil, fmt.Errorf("no credentials for %q in netrc %q",
+				host, conf.Netrc)
+		}
+
+		login := entry.Login
+		if login == "" {
+			login = username
+		}
+
+		return &http.BasicAuth{
+			Username: login,
+			Password: entry.Password,
+		}, nil
+	}
+
+	return nil, nil
+}
+
+func secretFromEnv(name string) (string, error) {
+	v := os.Getenv(name)
+	if v == "" {
+		return "", fmt.Errorf("environment variable %q is not set", name)
+	}
+
+	return v, nil
+}
+
+func expandHome(path string) (string, error) {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path, nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("resolve home directory: %w", err)
+	}
+
+	return filepath.Join(home, strings.TrimPrefix(path, "~")), nil
+}
@@ -0,0 +1,235 @@
+package elephantdocs
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/go-git/go-git/v6/plumbing/object"
+)
+
+// Changelog group titles, in the order that they are listed.
+const (
+	GroupBreaking = "Breaking changes"
+	GroupFeatures = "Features"
+	GroupFixes    = "Fixes"
+	GroupOther    = "Other"
+)
+
+var changelogGroupOrder = []string{
+	GroupBreaking, GroupFeatures, GroupFixes, GroupOther,
+}
+
+// ChangelogGroup is a group of changelog entries for a version.
+type ChangelogGroup struct {
+	Title   string
+	Entries []ChangelogEntry
+}
+
+// ChangelogEntry is a commit parsed as a conventional commit, see
+// https://www.conventionalcommits.org/. Commits that don't follow the
+// convention only have a Subject and Body.
+type ChangelogEntry struct {
+	Commit   *object.Commit `json:"-"`
+	Hash     string
+	Type     string `json:",omitempty"`
+	Scope    string `json:",omitempty"`
+	Subject  string
+	Body     string `json:",omitempty"`
+	Breaking bool
+	// BreakingNote is the description from the "BREAKING CHANGE"
+	// footers, which are left out of the Body.
+	BreakingNote string `json:",omitempty"`
+	// ProtoChanges summarises the changes that the commit made to the
+	// declarations of the API.
+	ProtoChanges []ProtoChange `json:",omitempty"`
+}
+
+var conventionalHeaderExp = regexp.MustCompile(
+	`^([a-zA-Z]+)(?:\(([^)]*)\))?(!)?: (.+)$`)
+
+// footerTokenExp matches the start of a conventional commits footer, like
+// "Refs: #123", "Reviewed-by: Jane" or "BREAKING CHANGE: ...".
+var footerTokenExp = regexp.MustCompile(
+	`^(?:BREAKING CHANGE|[A-Za-z][A-Za-z-]*)(?:: | #)`)
+
+// cutBreakingFooters removes the "BREAKING CHANGE" footers from a commit
+// body and returns their descriptions, and whether there were any. A footer
+// continues until the next footer token, so descriptions can span several
+// lines.
+func cutBreakingFooters(body string) (string, string, bool) {
+	var (
+		rest, notes []string
+		note        []string
+		inNote      bool
+		breaking    bool
+	)
+
+	endNote := func() {
+		if inNote {
+			notes = append(notes,
+				strings.TrimSpace(strings.Join(note, "\n")))
+		}
+
+		note = nil
+		inNote = false
+	}
+
+	for line := range strings.Lines(body) {
+		line = strings.TrimRight(line, "\r\n")
+
+		if footerTokenExp.MatchString(line) {
+			endNote()
+		}
+
+		for _, token := range []string{"BREAKING CHANGE:", "BREAKING-CHANGE:"} {
+			text, ok := strings.CutPrefix(line, token)
+			if ok {
+				inNote = true
+				breaking = true
+				line = text
+
+				break
+			}
+		}
+
+		if inNote {
+			note = append(note, line)
+		} else {
+			rest = append(rest, line)
+		}
+	}
+
+	endNote()
+
+	return strings.TrimSpace(strings.Join(rest, "\n")),
+		strings.TrimSpace(strings.Join(notes, "\n\n")), breaking
+}
+
+// parseChangelogEntry parses the commit message of a commit.
+func parseChangelogEntry(commit *object.Commit) ChangelogEntry {
+	header, body, _ := strings.Cut(
+		strings.TrimSpace(commit.Message), "\n")
+
+	entry := ChangelogEntry{
+		Commit:  commit,
+		Hash:    commit.Hash.String(),
+		Subject: strings.TrimSpace(header),
+		Body:    strings.TrimSpace(body),
+	}
+
+	m := conventionalHeaderExp.FindStringSubmatch(entry.Subject)
+	if m != nil {
+		entry.Type = strings.ToLower(m[1])
+		entry.Scope = m[2]
+		entry.Breaking = m[3] == "!"
+		entry.Subject = m[4]
+	}
+
+	body, note, breaking := cutBreakingFooters(entry.Body)
+
+	entry.Body = body
+	entry.BreakingNote = note
+	entry.Breaking = entry.Breaking || breaking
+
+	return entry
+}
+
+func (e ChangelogEntry) group() string {
+	switch {
+	case e.Breaking:
+		return GroupBreaking
+	case e.Type == "feat":
+		return GroupFeatures
+	case e.Type == "fix":
+		return GroupFixes
+	default:
+		return GroupOther
+	}
+}
+
+// changelogFilter drops noise commits from changelogs.
+type changelogFilter struct {
+	skipMerges  bool
+	ignoreTypes []string
+	ignoreExps  []*regexp.Regexp
+}
+
+func newChangelogFilter(conf *ChangelogConfig) (*changelogFilter, error) {
+	if conf == nil {
+		return &changelogFilter{}, nil
+	}
+
+	f := changelogFilter{
+		skipMerges:  conf.SkipMerges,
+		ignoreTypes: conf.IgnoreTypes,
+	}
+
+	for _, p := range conf.IgnorePatterns {
+		exp, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ignore pattern %q: %w", p, err)
+		}
+
+		f.ignoreExps = append(f.ignoreExps, exp)
+	}
+
+	return &f, nil
+}
+
+func (f *changelogFilter) ignore(e ChangelogEntry) bool {
+	if f.skipMerges && e.Commit.NumParents() > 1 {
+		return true
+	}
+
+	// Breaking changes are never noise.
+	if e.Breaking {
+		return false
+	}
+
+	if e.Type != "" && slices.Contains(f.ignoreTypes, e.Type) {
+		return true
+	}
+
+	for _, exp := range f.ignoreExps {
+		if exp.MatchString(e.Commit.Message) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// groupChangelog parses the commits of a version log and groups them, leaving
+// out the commits that the filter ignores.
+func groupChangelog(log []*object.Commit, filter *changelogFilter) []ChangelogGroup {
+	entries := make(map[string][]ChangelogEntry)
+
+	for _, c := range log {
+		e := parseChangelogEntry(c)
+
+		if filter.ignore(e) {
+			continue
+		}
+
+		g := e.group()
+
+		entries[g] = append(entries[g], e)
+	}
+
+	var groups []ChangelogGroup
+
+	for _, title := range changelogGroupOrder {
+		if len(entries[title]) == 0 {
+			continue
+		}
+
+		groups = append(groups, ChangelogGroup{
+			Title:   title,
+			Entries: entries[title],
+		})
+	}
+
+	return groups
+}
@@ -0,0 +1,85 @@
+package elephantdocs
+
+import (
+	"testing"
+
+	"github.com/go-git/go-git/v6/plumbing/object"
+)
+
+func TestParseChangelogEntryBreakingFooter(t *testing.T) {
+	commit := object.Commit{
+		Message: `feat(api): replace the document status
+
+The status is now a separate resource, so that it can have its own ACL.
+
+BREAKING CHANGE: Document.status has been removed, use
+GetStatus instead.
+
+The old field was never populated for new documents.
+Refs: #123
+Reviewed-by: Jane Doe
+`,
+	}
+
+	e := parseChangelogEntry(&commit)
+
+	if !e.Breaking {
+		t.Error("expected the entry to be breaking")
+	}
+
+	if e.Type != "feat" || e.Scope != "api" {
+		t.Errorf("got type %q and scope %q", e.Type, e.Scope)
+	}
+
+	wantNote := `Document.status has been removed, use
+GetStatus instead.
+
+The old field was never populated for new documents.`
+
+	if e.BreakingNote != wantNote {
+		t.Errorf("got breaking note:\n%s\nwant:\n%s", e.BreakingNote, wantNote)
+	}
+
+	wantBody := `The status is now a separate resource, so that it can have its own ACL.
+
+Refs: #123
+Reviewed-by: Jane Doe`
+
+	if e.Body != wantBody {
+		t.Errorf("got body:\n%s\nwant:\n%s", e.Body, wantBody)
+	}
+}
+
+func TestParseChangelogEntryEmptyBreakingFooter(t *testing.T) {
+	commit := object.Commit{
+		Message: "chore: bump dependencies\n\nBREAKING-CHANGE:\n",
+	}
+
+	e := parseChangelogEntry(&commit)
+
+	if !e.Breaking {
+		t.Error("expected the entry to be breaking")
+	}
+
+	if e.BreakingNote != "" || e.Body != "" {
+		t.Errorf("got note %q and body %q, want both empty",
+			e.BreakingNote, e.Body)
+	}
+}
+
+func TestParseChangelogEntryPlainMessage(t *testing.T) {
+	commit := object.Commit{
+		Message: "Update the README\n\nMention the BREAKING CHANGE: footer.\n",
+	}
+
+	e := parseChangelogEntry(&commit)
+
+	if e.Breaking || e.Type != "" {
+		t.Errorf("got breaking %v and type %q for a plain message",
+			e.Breaking, e.Type)
+	}
+
+	if e.Body != "Mention the BREAKING CHANGE: footer." {
+		t.Errorf("got body %q", e.Body)
+	}
+}
@@ -3,12 +3,16 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"net"
 	"net/http"
 	"os"
 	"time"
 
 	elephantdocs "github.com/ttab/elephant-docs"
+	"github.com/ttab/newsdoc"
 	"github.com/urfave/cli/v3"
 )
 
@@ -25,7 +29,6 @@ func main() {
 			&cli.StringFlag{
 				Name:      "out",
 				Usage:     "output directory for documentation",
-				Required:  true,
 				TakesFile: true,
 			},
 			&cli.StringFlag{
@@ -37,9 +40,60 @@ func main() {
 				Name:  "serve",
 				Usage: "Serve documentation for local preview: -serve :8080",
 			},
+			&cli.StringFlag{
+				Name:  "site-url",
+				Usage: "Public URL of the site, overrides site_url in the config",
+			},
 			&cli.BoolFlag{
 				Name:  "schema-prerelease",
-				Usage: "Use the latest pre-release tag for schema documentation",
+				Usage: "Deprecated: set \"channel\": \"prerelease\" in the schemas config instead",
+			},
+		},
+		Commands: []*cli.Command{
+			{
+				Name:      "schema-diff",
+				Usage:     "List the schema changes between two schema versions",
+				ArgsUsage: "<from> <to>",
+				Action:    schemaDiffAction,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:      "config",
+						Value:     "elephant-docs.json",
+						TakesFile: true,
+					},
+					&cli.StringFlag{
+						Name:  "format",
+						Usage: "Output format: text or json",
+						Value: "text",
+					},
+				},
+			},
+			{
+				Name:      "validate",
+				Usage:     "Validate NewsDoc documents against the schemas, reads from stdin if no files are given",
+				ArgsUsage: "[file.json...]",
+				Action:    validateAction,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:      "config",
+						Value:     "elephant-docs.json",
+						TakesFile: true,
+					},
+					&cli.StringFlag{
+						Name:  "schema-version",
+						Usage: "Schema version to validate against, defaults to the latest version",
+					},
+					&cli.StringFlag{
+						Name:      "schemas-dir",
+						Usage:     "Validate against a local checkout of the schema repository",
+						TakesFile: true,
+					},
+					&cli.StringFlag{
+						Name:  "format",
+						Usage: "Output format: text or json",
+						Value: "text",
+					},
+				},
 			},
 		},
 	}
@@ -57,9 +111,16 @@ func generateAction(ctx context.Context, cmd *cli.Command) error {
 		outDir           = cmd.String("out")
 		basePath         = cmd.String("base-path")
 		serveAddr        = cmd.String("serve")
+		siteURL          = cmd.String("site-url")
 		schemaPrerelease = cmd.Bool("schema-prerelease")
 	)
 
+	// Not a required flag, as that would apply to the subcommands as
+	// well.
+	if outDir == "" {
+		return errors.New("the output directory must be set with -out")
+	}
+
 	start := time.Now()
 
 	err := os.RemoveAll(outDir)
@@ -72,19 +133,29 @@ func generateAction(ctx context.Context, cmd *cli.Command) error {
 		return fmt.Errorf("create output directory: %w", err)
 	}
 
-	var conf elephantdocs.Config
-
-	confData, err := os.ReadFile(configPath)
+	conf, err := loadConfig(configPath)
 	if err != nil {
-		return fmt.Errorf("read config file: %w", err)
+		return err
 	}
 
-	err = json.Unmarshal(confData, &conf)
-	if err != nil {
-		return fmt.Errorf("unmarshal config: %w", err)
+	if schemaPrerelease && conf.Schemas != nil {
+		conf.Schemas.Channel = elephantdocs.ChannelPrerelease
+	}
+
+	if siteURL != "" {
+		conf.SiteURL = siteURL
+	}
+
+	// Local previews link to the preview server unless the site URL has
+	// been configured.
+	if conf.SiteURL == "" && serveAddr != "" {
+		conf.SiteURL, err = previewSiteURL(serveAddr, basePath)
+		if err != nil {
+			return err
+		}
 	}
 
-	err = elephantdocs.Generate(ctx, outDir, basePath, conf, schemaPrerelease, TUIPrintln)
+	err = elephantdocs.Generate(ctx, outDir, basePath, conf, TUIPrintln)
 	if err != nil {
 		return fmt.Errorf("generate documentation: %w", err)
 	}
@@ -106,6 +177,254 @@ func generateAction(ctx context.Context, cmd *cli.Command) error {
 	return nil
 }
 
+func schemaDiffAction(_ context.Context, cmd *cli.Command) error {
+	var (
+		configPath = cmd.String("config")
+		format     = cmd.String("format")
+		from       = cmd.Args().Get(0)
+		to         = cmd.Args().Get(1)
+	)
+
+	if cmd.Args().Len() != 2 {
+		return errors.New("expected the two schema versions to compare")
+	}
+
+	switch format {
+	case "text", "json":
+	default:
+		return fmt.Errorf("unknown output format %q", format)
+	}
+
+	conf, err := loadConfig(configPath)
+	if err != nil {
+		return err
+	}
+
+	if conf.Schemas == nil {
+		return errors.New("no schemas have been configured")
+	}
+
+	diff, err := elephantdocs.DiffSchemaTags(*conf.Schemas, from, to)
+	if err != nil {
+		return fmt.Errorf("diff schemas: %w", err)
+	}
+
+	if format == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+
+		err := enc.Encode(diff)
+		if err != nil {
+			return fmt.Errorf("write diff: %w", err)
+		}
+
+		return nil
+	}
+
+	fmt.Printf("Schema changes in %s since %s\n", diff.To, diff.From)
+
+	if diff.IsEmpty() {
+		fmt.Println("\nNo changes.")
+	}
+
+	for _, section := range []struct {
+		title   string
+		changes []elephantdocs.SchemaChange
+	}{
+		{"Document types", diff.Documents},
+		{"Blocks", diff.Blocks},
+		{"Enums", diff.Enums},
+		{"HTML policies", diff.Policies},
+	} {
+		if len(section.changes) == 0 {
+			continue
+		}
+
+		fmt.Printf("\n%s:\n", section.title)
+
+		for _, c := range section.changes {
+			fmt.Printf("  %s\n", c)
+		}
+	}
+
+	return nil
+}
+
+// validationReport is the validation result for a document.
+type validationReport struct {
+	File     string                           `json:"file"`
+	Valid    bool                             `json:"valid"`
+	Problems []elephantdocs.ValidationProblem `json:"problems,omitempty"`
+}
+
+func validateAction(ctx context.Context, cmd *cli.Command) error {
+	var (
+		configPath = cmd.String("config")
+		version    = cmd.String("schema-version")
+		schemasDir = cmd.String("schemas-dir")
+		format     = cmd.String("format")
+		files      = cmd.Args().Slice()
+	)
+
+	switch format {
+	case "text", "json":
+	default:
+		return fmt.Errorf("unknown output format %q", format)
+	}
+
+	conf, err := loadConfig(configPath)
+	if err != nil {
+		return err
+	}
+
+	validator, err := elephantdocs.NewDocumentValidator(conf, version, schemasDir)
+	if err != nil {
+		return fmt.Errorf("load schemas: %w", err)
+	}
+
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+
+	var reports []validationReport
+
+	for _, name := range files {
+		r, err := validateFile(ctx, validator, name)
+		if err != nil {
+			return err
+		}
+
+		reports = append(reports, r...)
+	}
+
+	var invalid int
+
+	for _, r := range reports {
+		if !r.Valid {
+			invalid++
+		}
+	}
+
+	if format == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+
+		err := enc.Encode(reports)
+		if err != nil {
+			return fmt.Errorf("write report: %w", err)
+		}
+	} else {
+		for _, r := range reports {
+			if r.Valid {
+				fmt.Printf("%s: valid\n", r.File)
+
+				continue
+			}
+
+			noun := "problems"
+			if len(r.Problems) == 1 {
+				noun = "problem"
+			}
+
+			fmt.Printf("%s: %d %s\n", r.File, len(r.Problems), noun)
+
+			for _, p := range r.Problems {
+				fmt.Printf("  %s\n", p)
+
+				if p.Link != "" {
+					fmt.Printf("    %s\n", p.Link)
+				}
+			}
+		}
+	}
+
+	if invalid > 0 {
+		return fmt.Errorf("%d of %d documents are invalid (schema version %s)",
+			invalid, len(reports), validator.Version)
+	}
+
+	return nil
+}
+
+// validateFile validates the documents in a file, or on stdin if the name is
+// "-". A file can contain a stream of documents.
+func validateFile(
+	ctx context.Context, validator *elephantdocs.DocumentValidator, name string,
+) ([]validationReport, error) {
+	in := os.Stdin
+	label := "stdin"
+
+	if name != "-" {
+		f, err := os.Open(name)
+		if err != nil {
+			return nil, fmt.Errorf("open document: %w", err)
+		}
+
+		defer f.Close()
+
+		in = f
+		label = name
+	}
+
+	var reports []validationReport
+
+	dec := json.NewDecoder(in)
+
+	for n := 1; ; n++ {
+		file := label
+		if n > 1 {
+			file = fmt.Sprintf("%s#%d", label, n)
+		}
+
+		var doc newsdoc.Document
+
+		err := dec.Decode(&doc)
+		if errors.Is(err, io.EOF) && n > 1 {
+			break
+		} else if err != nil {
+			// The rest of the stream can't be read after a
+			// syntax error.
+			reports = append(reports, validationReport{
+				File: file,
+				Problems: []elephantdocs.ValidationProblem{{
+					Error: fmt.Sprintf("invalid JSON: %v", err),
+				}},
+			})
+
+			break
+		}
+
+		problems, err := validator.Validate(ctx, &doc)
+		if err != nil {
+			return nil, fmt.Errorf("validate %s: %w", file, err)
+		}
+
+		reports = append(reports, validationReport{
+			File:     file,
+			Valid:    len(problems) == 0,
+			Problems: problems,
+		})
+	}
+
+	return reports, nil
+}
+
+func loadConfig(path string) (elephantdocs.Config, error) {
+	var conf elephantdocs.Config
+
+	confData, err := os.ReadFile(path)
+	if err != nil {
+		return conf, fmt.Errorf("read config file: %w", err)
+	}
+
+	err = json.Unmarshal(confData, &conf)
+	if err != nil {
+		return conf, fmt.Errorf("unmarshal config: %w", err)
+	}
+
+	return conf, nil
+}
+
 func TUIPrintln(format string, a ...any) {
 	_, err := fmt.Fprintf(os.Stderr, format, a...)
 	if err != nil {
@@ -116,3 +435,17 @@ func TUIPrintln(format string, a ...any) {
 
 	println()
 }
+
+// previewSiteURL returns the site URL for a local preview server.
+func previewSiteURL(serveAddr string, basePath string) (string, error) {
+	host, port, err := net.SplitHostPort(serveAddr)
+	if err != nil {
+		return "", fmt.Errorf("invalid serve address: %w", err)
+	}
+
+	if host == "" {
+		host = "localhost"
+	}
+
+	return "http://" + net.JoinHostPort(host, port) + basePath, nil
+}
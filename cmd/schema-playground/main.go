@@ -0,0 +1,127 @@
+//go:build js && wasm
+
+// Command schema-playground is a WebAssembly module that validates NewsDoc
+// documents in the browser for the schema validation playground.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"syscall/js"
+
+	"github.com/ttab/elephant-docs/internal/schemalink"
+	"github.com/ttab/newsdoc"
+	"github.com/ttab/revisor"
+)
+
+var (
+	validator *revisor.Validator
+	links     schemalink.Index
+)
+
+// problem is a validation result as it's reported to the playground page.
+type problem struct {
+	Entity  []revisor.EntityRef `json:"entity,omitempty"`
+	Error   string              `json:"error"`
+	Message string              `json:"message"`
+	// HRef links to the documentation of the entity.
+	HRef string `json:"href"`
+}
+
+type response struct {
+	Problems []problem `json:"problems"`
+	Error    string    `json:"error,omitempty"`
+}
+
+func main() {
+	js.Global().Set("revisorPlayground", js.ValueOf(map[string]any{
+		"load":     js.FuncOf(load),
+		"validate": js.FuncOf(validate),
+	}))
+
+	select {}
+}
+
+// load creates the validator from a JSON array of constraint sets, and loads
+// the JSON link index that validation problems are linked with. It returns an
+// error message, or null if the constraint sets were loaded.
+func load(_ js.Value, args []js.Value) any {
+	if len(args) != 2 {
+		return "expected the constraint sets and the link index as arguments"
+	}
+
+	var sets []revisor.ConstraintSet
+
+	err := json.Unmarshal([]byte(args[0].String()), &sets)
+	if err != nil {
+		return fmt.Sprintf("decode constraint sets: %v", err)
+	}
+
+	var index schemalink.Index
+
+	err = json.Unmarshal([]byte(args[1].String()), &index)
+	if err != nil {
+		return fmt.Sprintf("decode link index: %v", err)
+	}
+
+	v, err := revisor.NewValidator(sets...)
+	if err != nil {
+		return fmt.Sprintf("create validator: %v", err)
+	}
+
+	validator = v
+	links = index
+
+	return nil
+}
+
+// validate validates a JSON document and returns a JSON encoded response.
+func validate(_ js.Value, args []js.Value) any {
+	var res response
+
+	switch {
+	case validator == nil:
+		res.Error = "the constraint sets haven't been loaded"
+	case len(args) != 1:
+		res.Error = "expected the document as the only argument"
+	default:
+		res = validateDocument(args[0].String())
+	}
+
+	data, err := json.Marshal(res)
+	if err != nil {
+		return fmt.Sprintf(`{"error":%q}`, err.Error())
+	}
+
+	return string(data)
+}
+
+func validateDocument(data string) response {
+	var doc newsdoc.Document
+
+	err := json.Unmarshal([]byte(data), &doc)
+	if err != nil {
+		return response{Error: fmt.Sprintf("invalid JSON: %v", err)}
+	}
+
+	results, err := validator.ValidateDocument(context.Background(), &doc)
+	if err != nil {
+		return response{Error: fmt.Sprintf("validate document: %v", err)}
+	}
+
+	res := response{
+		Problems: make([]problem, len(results)),
+	}
+
+	for i, r := range results {
+		res.Problems[i] = problem{
+			Entity:  r.Entity,
+			Error:   r.Error,
+			Message: r.String(),
+			HRef:    links.Link(&doc, r.Entity),
+		}
+	}
+
+	return res
+}
@@ -0,0 +1,324 @@
+package elephantdocs
+
+import (
+	"fmt"
+	"html/template"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/go-git/go-git/v6/plumbing"
+	"golang.org/x/mod/module"
+)
+
+// CompatibilityPage lists the versions of the included modules that each
+// module version was built against.
+type CompatibilityPage struct {
+	Modules []CompatibilityModule
+}
+
+type CompatibilityModule struct {
+	Title string
+	Name  string
+	// Dependencies are the column headings, without versions.
+	Dependencies []DependencyRef
+	Rows         []CompatibilityRow
+}
+
+type CompatibilityRow struct {
+	Version      string
+	HRef         string
+	IsPrerelease bool
+	IsUnreleased bool
+	// Cells has one entry per dependency of the module, in the same order
+	// as CompatibilityModule.Dependencies.
+	Cells []DependencyRef
+}
+
+// DependencyRef is a reference to the version of an included module.
+type DependencyRef struct {
+	Module string
+	Title  string
+	// Version is the tag of the version, or the pseudo-version or commit
+	// hash for untagged commits. Empty if the module isn't a dependency.
+	Version  string
+	HRef     string
+	Untagged bool
+}
+
+// resolveDependencyVersions sets the DependencyVersions of every version of
+// the modules to the versions of the included modules that they require.
+// Untagged commits are recorded using their pseudo-version, or their
+// abbreviated hash for local replacements.
+func resolveDependencyVersions(modules map[string]*Module) error {
+	for _, mod := range modules {
+		if len(mod.Include) == 0 {
+			continue
+		}
+
+		versions := mod.Versions
+		if mod.Unreleased != nil {
+			versions = append(slices.Clone(versions), mod.Unreleased)
+		}
+
+		for _, version := range versions {
+			tree, err := mod.Tree(version.Commit)
+			if err != nil {
+				return fmt.Errorf("get tree of %s@%s: %w",
+					mod.Name, version.Tag, err)
+			}
+
+			deps, err := readDepVersions(tree, mod.Include, modules)
+			if err != nil {
+				return fmt.Errorf("read dependencies of %s@%s: %w",
+					mod.Name, version.Tag, err)
+			}
+
+			src := depSource{
+				Module: mod,
+				Dir:    mod.Dir,
+				Commit: version.Commit,
+			}
+
+			depVersions := make(map[string]string, len(deps))
+
+			for _, dep := range deps {
+				resolved, err := resolveDependency(modules, src, dep)
+				if err != nil {
+					return fmt.Errorf("resolve dependency %q of %s@%s: %w",
+						dep.Module, mod.Name, version.Tag, err)
+				}
+
+				v := resolved.Version.Tag
+
+				// Keep pseudo-versions as they are, they are
+				// more informative than the commit hash.
+				switch {
+				case resolved.UntaggedCommit == "":
+				case dep.Replace != nil && module.IsPseudoVersion(dep.Replace.Version):
+					v = dep.Replace.Version
+				case dep.Replace == nil && module.IsPseudoVersion(dep.Version):
+					v = dep.Version
+				default:
+					v = resolved.UntaggedCommit[:12]
+				}
+
+				depVersions[resolved.Module.Name] = v
+			}
+
+			version.DependencyVersions = depVersions
+		}
+	}
+
+	return nil
+}
+
+// dependencyRefs returns references to the versions of the included modules
+// that a module version depends on, sorted by module title.
+func dependencyRefs(
+	modules map[string]*Module, version *ModuleVersion,
+) []DependencyRef {
+	refs := make([]DependencyRef, 0, len(version.DependencyVersions))
+
+	for name, v := range version.DependencyVersions {
+		refs = append(refs, dependencyRef(modules, name, v))
+	}
+
+	slices.SortFunc(refs, func(a, b DependencyRef) int {
+		return strings.Compare(a.Title, b.Title)
+	})
+
+	return refs
+}
+
+func dependencyRef(
+	modules map[string]*Module, name string, version string,
+) DependencyRef {
+	ref := DependencyRef{
+		Module:  name,
+		Title:   name,
+		Version: version,
+	}
+
+	depMod, ok := modules[name]
+	if !ok {
+		return ref
+	}
+
+	ref.Title = depMod.Title
+
+	linkVersion, tagged := depMod.VersionLookup[version]
+	if !tagged {
+		ref.Untagged = true
+		linkVersion = untaggedLinkVersion(depMod, version)
+	}
+
+	linkVersion = documentedVersion(depMod, linkVersion)
+
+	if api := firstAPI(depMod); api != "" {
+		ref.HRef = fmt.Sprintf("/apis/%s/%s", api, linkVersion.Tag)
+	}
+
+	return ref
+}
+
+// untaggedLinkVersion returns the closest tagged version to a pseudo-version
+// or commit hash, falling back to the latest version if the commit can't be
+// found.
+func untaggedLinkVersion(mod *Module, rev string) *ModuleVersion {
+	if module.IsPseudoVersion(rev) {
+		base, err := module.PseudoVersionBase(rev)
+		if v, ok := mod.VersionLookup[base]; err == nil && ok {
+			return v
+		}
+
+		rev, err = module.PseudoVersionRev(rev)
+		if err != nil {
+			return mod.LatestVersion
+		}
+	}
+
+	if mod.Source == SourceProxy {
+		return mod.LatestVersion
+	}
+
+	hash, err := mod.Repo.ResolveRevision(plumbing.Revision(rev))
+	if err != nil {
+		return mod.LatestVersion
+	}
+
+	commit, err := mod.Repo.CommitObject(*hash)
+	if err != nil {
+		return mod.LatestVersion
+	}
+
+	return closestTaggedVersion(mod, commit)
+}
+
+// firstAPI returns the name of the first API of the module in alphabetical
+// order, used to link to a module version.
+func firstAPI(mod *Module) string {
+	names := make([]string, 0, len(mod.APIs))
+
+	for name := range mod.APIs {
+		names = append(names, name)
+	}
+
+	if len(names) == 0 {
+		return ""
+	}
+
+	slices.Sort(names)
+
+	return names[0]
+}
+
+// hasIncludes returns true if any of the modules include APIs from other
+// modules.
+func hasIncludes(modules map[string]*Module) bool {
+	for _, mod := range modules {
+		if len(mod.Include) > 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+func renderCompatibilityPage(
+	tpl *template.Template,
+	outDir string,
+	menu []MenuItem,
+	modules map[string]*Module,
+) error {
+	var page CompatibilityPage
+
+	for _, mod := range modules {
+		if len(mod.Include) == 0 {
+			continue
+		}
+
+		cm := CompatibilityModule{
+			Title: mod.Title,
+			Name:  mod.Name,
+		}
+
+		versions := mod.Versions
+		if mod.Unreleased != nil {
+			versions = append([]*ModuleVersion{mod.Unreleased}, versions...)
+		}
+
+		var depNames []string
+
+		for _, v := range versions {
+			for name := range v.DependencyVersions {
+				if !slices.Contains(depNames, name) {
+					depNames = append(depNames, name)
+				}
+			}
+		}
+
+		slices.Sort(depNames)
+
+		for _, name := range depNames {
+			title := name
+			if depMod, ok := modules[name]; ok {
+				title = depMod.Title
+			}
+
+			cm.Dependencies = append(cm.Dependencies, DependencyRef{
+				Module: name,
+				Title:  title,
+			})
+		}
+
+		api := firstAPI(mod)
+
+		for _, v := range versions {
+			row := CompatibilityRow{
+				Version:      v.Tag,
+				IsPrerelease: v.IsPrerelease,
+				IsUnreleased: v.IsUnreleased,
+			}
+
+			if api != "" {
+				row.HRef = fmt.Sprintf("/apis/%s/%s", api, v.Tag)
+			}
+
+			for _, name := range depNames {
+				depVersion, ok := v.DependencyVersions[name]
+				if !ok {
+					row.Cells = append(row.Cells, DependencyRef{
+						Module: name,
+					})
+
+					continue
+				}
+
+				row.Cells = append(row.Cells,
+					dependencyRef(modules, name, depVersion))
+			}
+
+			cm.Rows = append(cm.Rows, row)
+		}
+
+		page.Modules = append(page.Modules, cm)
+	}
+
+	slices.SortFunc(page.Modules, func(a, b CompatibilityModule) int {
+		return strings.Compare(a.Title, b.Title)
+	})
+
+	err := renderPage(
+		filepath.Join(outDir, "compatibility"),
+		tpl, "compatibility.html", Page{
+			Title:    "Compatibility",
+			Menu:     markActive(menu, "/compatibility"),
+			Contents: page,
+		})
+	if err != nil {
+		return fmt.Errorf("render compatibility page: %w", err)
+	}
+
+	return nil
+}
@@ -3,13 +3,33 @@ package elephantdocs
 type Config struct {
 	Modules []ModuleConfig     `json:"modules"`
 	Schemas *SchemaGroupConfig `json:"schemas,omitempty"`
+	// SiteURL is the public URL of the site, including any base path,
+	// used to make absolute links in feeds. It's required as feeds need
+	// absolute links.
+	SiteURL string `json:"site_url,omitempty"`
+	// FeedAuthor is the author of the feeds. Feed entries are attributed
+	// to the authors of the tagged commits.
+	FeedAuthor string `json:"feed_author,omitempty"`
 }
 
 type SchemaGroupConfig struct {
 	Title string            `json:"title"`
 	Repo  string            `json:"repo"`
 	Clone string            `json:"clone,omitempty"`
+	Auth  *AuthConfig       `json:"auth,omitempty"`
 	Sets  []SchemaSetConfig `json:"sets"`
+
+	// Channel is the release channel used to pick the latest schema
+	// version.
+	Channel string `json:"channel,omitempty"`
+	// Versions controls which schema versions are documented.
+	Versions *VersionPolicy `json:"versions,omitempty"`
+	// Playground adds an in-browser validation playground to the schema
+	// pages. It requires the Go toolchain to build the WebAssembly
+	// module.
+	Playground bool `json:"playground,omitempty"`
+	// Links configures the links to the schema repository.
+	Links *LinkConfig `json:"links,omitempty"`
 }
 
 type SchemaSetConfig struct {
@@ -22,14 +42,167 @@ type ModuleConfig struct {
 	Title   string                   `json:"title"`
 	Name    string                   `json:"name"`
 	Clone   string                   `json:"clone,omitempty"`
+	Auth    *AuthConfig              `json:"auth,omitempty"`
+	Source  string                   `json:"source,omitempty"`
+	Proxy   string                   `json:"proxy,omitempty"`
 	APIs    map[string]APIConfig     `json:"apis"`
 	Include map[string]IncludeConfig `json:"include"`
+
+	// HeadDocs renders the documentation for the latest version from the
+	// HEAD of the default branch, so that documentation improvements don't
+	// require a new release.
+	HeadDocs bool `json:"head_docs,omitempty"`
+	// Unreleased renders a branch as its own "unreleased" version.
+	Unreleased *UnreleasedConfig `json:"unreleased,omitempty"`
+	// Channel is the release channel used to pick the latest version.
+	Channel string `json:"channel,omitempty"`
+	// Versions controls which versions of the module are documented.
+	Versions *VersionPolicy `json:"versions,omitempty"`
+	// Dir is the directory of the module in its repository, for modules
+	// that don't live at the repository root.
+	Dir string `json:"dir,omitempty"`
+	// TagPrefix is the prefix of the version tags of the module. Defaults
+	// to "<dir>/" for modules in a subdirectory, following the Go
+	// convention.
+	TagPrefix string `json:"tag_prefix,omitempty"`
+	// Changelog controls which commits are listed in the changelogs.
+	Changelog *ChangelogConfig `json:"changelog,omitempty"`
+	// Links configures the links to the source repository.
+	Links *LinkConfig `json:"links,omitempty"`
+	// Lifecycle marks versions as deprecated, end-of-life or yanked.
+	Lifecycle []LifecycleRule `json:"lifecycle,omitempty"`
+	// LifecycleFile is the path to a JSON file with a list of lifecycle
+	// rules in the module directory. The file is read from the HEAD of
+	// the default branch, or from the newest version for modules from a
+	// module proxy. Rules in the configuration take precedence.
+	LifecycleFile string `json:"lifecycle_file,omitempty"`
+}
+
+// LifecycleRule sets the lifecycle status of a version or a range of
+// versions.
+type LifecycleRule struct {
+	// Versions is a version tag like "v1.2.3", or a semver constraint
+	// like "< 1.4". Pre-releases are checked as their release version.
+	Versions string `json:"versions"`
+	// Status is one of "deprecated", "eol" or "yanked".
+	Status string `json:"status"`
+	Reason string `json:"reason,omitempty"`
+	// Replacement is the version that users should move to, defaults to
+	// the latest version.
+	Replacement string `json:"replacement,omitempty"`
+}
+
+// LinkConfig configures links to the source repository of a module. The link
+// templates can use the placeholders "{repo}", "{hash}", "{tag}", "{ref}",
+// "{path}" and "{number}", and default to the templates of the host.
+type LinkConfig struct {
+	// Host is one of "github", "gitlab" or "gitea", and is detected from
+	// the repository URL if not set.
+	Host string `json:"host,omitempty"`
+	// Repo is the web URL of the repository, defaults to a URL derived
+	// from the clone URL.
+	Repo        string `json:"repo,omitempty"`
+	Commit      string `json:"commit,omitempty"`
+	Tag         string `json:"tag,omitempty"`
+	File        string `json:"file,omitempty"`
+	PullRequest string `json:"pull_request,omitempty"`
+}
+
+// ChangelogConfig filters noise commits from changelogs. Breaking changes are
+// always listed.
+type ChangelogConfig struct {
+	// SkipMerges drops merge commits.
+	SkipMerges bool `json:"skip_merges,omitempty"`
+	// IgnoreTypes drops conventional commits of the given types, like
+	// "chore" or "ci".
+	IgnoreTypes []string `json:"ignore_types,omitempty"`
+	// IgnorePatterns drops commits with messages that match any of the
+	// regular expressions.
+	IgnorePatterns []string `json:"ignore_patterns,omitempty"`
+}
+
+// VersionPolicy controls which tagged versions of a module get documented.
+type VersionPolicy struct {
+	// Min is the oldest version to document.
+	Min string `json:"min,omitempty"`
+	// Constraints are semver constraints like ">= 1.2, < 2". A version is
+	// documented if it matches any of them. Pre-releases are checked as
+	// their release version.
+	Constraints []string `json:"constraints,omitempty"`
+	// LatestPatchOnly only documents the latest patch release of each
+	// minor version, and its newest pre-release if there is one that is
+	// newer than the patch release.
+	LatestPatchOnly bool `json:"latest_patch_only,omitempty"`
+	// ExcludeOldPrereleases drops pre-releases that are older than the
+	// latest stable version.
+	ExcludeOldPrereleases bool `json:"exclude_old_prereleases,omitempty"`
+	// Exclude lists version tags that shouldn't be documented.
+	Exclude []string `json:"exclude,omitempty"`
+}
+
+// Module sources.
+const (
+	// SourceGit clones the module repository and reads versions from its
+	// tags. This is the default.
+	SourceGit = "git"
+	// SourceProxy lists and downloads versions through a Go module proxy.
+	SourceProxy = "proxy"
+)
+
+// Release channels.
+const (
+	// ChannelStable uses the newest stable version as the latest version,
+	// falling back to the newest pre-release if there are no stable
+	// versions. This is the default.
+	ChannelStable = "stable"
+	// ChannelPrerelease uses the newest version as the latest version,
+	// regardless of whether it's a pre-release or not.
+	ChannelPrerelease = "prerelease"
+)
+
+// UnreleasedConfig configures the unreleased documentation channel.
+type UnreleasedConfig struct {
+	// Branch to render, defaults to the default branch of the repository.
+	Branch string `json:"branch,omitempty"`
 }
 
 type APIConfig struct {
 	Title string `json:"title"`
+	// Changelog controls which changes are listed in the changelog of the
+	// API.
+	Changelog *APIChangelogConfig `json:"changelog,omitempty"`
+}
+
+// APIChangelogConfig scopes the changelog of an API to the commits that
+// changed files matching the include globs, and not only files matching the
+// exclude globs. Globs are relative to the module directory and "**" matches
+// any number of directories. Include defaults to "<api>/".
+type APIChangelogConfig struct {
+	Include []string `json:"include,omitempty"`
+	Exclude []string `json:"exclude,omitempty"`
 }
 
 type IncludeConfig struct {
 	From string `json:"from"`
 }
+
+// AuthConfig describes how to authenticate when cloning a private
+// repository. Secrets are never part of the configuration, only references to
+// where they can be found.
+type AuthConfig struct {
+	// TokenEnv is the name of an environment variable that holds an access
+	// token for HTTPS clone URLs.
+	TokenEnv string `json:"token_env,omitempty"`
+	// Username to use together with the token, or for SSH clone URLs.
+	// Defaults to "git".
+	Username string `json:"username,omitempty"`
+	// SSHKey is the path to a private key for SSH clone URLs like
+	// "git@github.com:ttab/example.git". The SSH agent is used if no key
+	// is configured.
+	SSHKey string `json:"ssh_key,omitempty"`
+	// SSHKeyPassphraseEnv is the name of an environment variable that holds
+	// the passphrase for an encrypted SSH key.
+	SSHKeyPassphraseEnv string `json:"ssh_key_passphrase_env,omitempty"`
+	// Netrc is the path to a netrc file to read HTTPS credentials from.
+	Netrc string `json:"netrc,omitempty"`
+}
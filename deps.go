@@ -0,0 +1,301 @@
+package elephantdocs
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/go-git/go-git/v6/plumbing"
+	"github.com/go-git/go-git/v6/plumbing/object"
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+)
+
+// depSource is a module at a commit that dependencies are resolved from.
+type depSource struct {
+	Module *Module
+	// Dir is the directory of the module in the repository, which differs
+	// from Module.Dir for modules that were reached through a local
+	// replace directive.
+	Dir    string
+	Commit *object.Commit
+}
+
+// collectDependencyFiles adds the proto files of the included APIs of the
+// module at a commit to files. Includes are resolved transitively using the
+// go.mod and include configuration of each dependency, closer dependencies
+// take precedence over the ones further away.
+func collectDependencyFiles(
+	modules map[string]*Module,
+	mod *Module, commit *object.Commit,
+	files map[string]ProtoHandle,
+) error {
+	queue := []depSource{{
+		Module: mod,
+		Dir:    mod.Dir,
+		Commit: commit,
+	}}
+
+	// Modules can depend on each other, so keep track of the module
+	// versions that have been visited to break cycles.
+	visited := make(map[string]bool)
+
+	for len(queue) > 0 {
+		src := queue[0]
+		queue = queue[1:]
+
+		key := src.Module.Name + "@" + src.Commit.Hash.String()
+		if visited[key] {
+			continue
+		}
+
+		visited[key] = true
+
+		tree, err := (&Module{Dir: src.Dir}).Tree(src.Commit)
+		if err != nil {
+			return fmt.Errorf("get tree of %q: %w", src.Module.Name, err)
+		}
+
+		dependencies, err := readDepVersions(tree, src.Module.Include, modules)
+		if err != nil {
+			return fmt.Errorf("resolve dependency versions of %q: %w",
+				src.Module.Name, err)
+		}
+
+		for _, name := range slices.Sorted(maps.Keys(dependencies)) {
+			dep := dependencies[name]
+
+			resolved, err := resolveDependency(modules, src, dep)
+			if err != nil {
+				return fmt.Errorf("resolve dependency %q of %q: %w",
+					dep.Module, src.Module.Name, err)
+			}
+
+			depMod := resolved.Module
+
+			_, ok := depMod.APIs[dep.API]
+			if !ok {
+				return fmt.Errorf("module %q doesn't expose the API %q",
+					depMod.Name, dep.API)
+			}
+
+			protos, err := parseProtoFiles(resolved.Tree, dep.API)
+			if err != nil {
+				return fmt.Errorf("parse files in dependency %q in %q: %w",
+					dep.API, depMod.Name, err)
+			}
+
+			// Link to the closest documented version if the
+			// version policy excludes the exact version.
+			linkVersion := documentedVersion(depMod, resolved.Version)
+
+			for _, pd := range protos {
+				if _, exists := files[pd.File]; exists {
+					continue
+				}
+
+				files[pd.File] = ProtoHandle{
+					API:     dep.API,
+					Module:  depMod.Name,
+					Version: linkVersion.Tag,
+					Commit:  resolved.UntaggedCommit,
+					Proto:   pd,
+				}
+			}
+
+			queue = append(queue, depSource{
+				Module: depMod,
+				Dir:    resolved.Dir,
+				Commit: resolved.Commit,
+			})
+		}
+	}
+
+	return nil
+}
+
+// resolvedDep is a dependency resolved to the module and tree to read its
+// files from.
+type resolvedDep struct {
+	Module *Module
+	Tree   *object.Tree
+	// Dir and Commit are where the tree was read from.
+	Dir    string
+	Commit *object.Commit
+	// Version is the tagged version to link to.
+	Version *ModuleVersion
+	// UntaggedCommit is the hash of the commit that the files were read
+	// from when it isn't a tagged version.
+	UntaggedCommit string
+}
+
+// resolveDependency finds the files of a dependency, following replace
+// directives and resolving pseudo-versions to their commits.
+func resolveDependency(
+	modules map[string]*Module, src depSource, dep depSpec,
+) (*resolvedDep, error) {
+	name, depVersion := dep.Module, dep.Version
+
+	if dep.Replace != nil {
+		if modfile.IsDirectoryPath(dep.Replace.Path) {
+			return resolveLocalReplace(modules, src, dep)
+		}
+
+		name, depVersion = dep.Replace.Path, dep.Replace.Version
+	}
+
+	depMod, ok := modules[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown module %q", name)
+	}
+
+	tagged, ok := depMod.VersionLookup[depVersion]
+	if ok {
+		commit, err := depMod.versionCommit(tagged)
+		if err != nil {
+			return nil, fmt.Errorf("get commit of %q: %w", depVersion, err)
+		}
+
+		tree, err := depMod.Tree(commit)
+		if err != nil {
+			return nil, fmt.Errorf("get tree of %q: %w", depVersion, err)
+		}
+
+		return &resolvedDep{
+			Module:  depMod,
+			Tree:    tree,
+			Dir:     depMod.Dir,
+			Commit:  commit,
+			Version: tagged,
+		}, nil
+	}
+
+	if !module.IsPseudoVersion(depVersion) {
+		return nil, fmt.Errorf("no tagged version %q of %q",
+			depVersion, name)
+	}
+
+	if depMod.Source == SourceProxy {
+		return nil, fmt.Errorf(
+			"pseudo-version %q of %q requires a git source",
+			depVersion, name)
+	}
+
+	rev, err := module.PseudoVersionRev(depVersion)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pseudo-version %q: %w",
+			depVersion, err)
+	}
+
+	hash, err := depMod.Repo.ResolveRevision(plumbing.Revision(rev))
+	if err != nil {
+		return nil, fmt.Errorf("resolve commit %q of %q: %w",
+			rev, name, err)
+	}
+
+	commit, err := depMod.Repo.CommitObject(*hash)
+	if err != nil {
+		return nil, fmt.Errorf("get commit %q of %q: %w", rev, name, err)
+	}
+
+	resolved, err := untaggedDep(depMod, depMod.Dir, commit)
+	if err != nil {
+		return nil, err
+	}
+
+	// The base of a pseudo-version is the tag that the commit is a
+	// descendant of, prefer that over guessing from commit times.
+	base, err := module.PseudoVersionBase(depVersion)
+	if err == nil && resolved.UntaggedCommit != "" {
+		if bv, ok := depMod.VersionLookup[base]; ok {
+			resolved.Version = bv
+		}
+	}
+
+	return resolved, nil
+}
+
+// resolveLocalReplace resolves a replace directive that points to a directory
+// in the same repository, like the modules of a monorepo. The files are read
+// from the same commit as the version that depends on them.
+func resolveLocalReplace(
+	modules map[string]*Module, src depSource, dep depSpec,
+) (*resolvedDep, error) {
+	depMod, ok := modules[dep.Module]
+	if !ok {
+		return nil, fmt.Errorf("unknown module %q", dep.Module)
+	}
+
+	if path.IsAbs(dep.Replace.Path) {
+		return nil, fmt.Errorf(
+			"replace with absolute path %q can't be resolved",
+			dep.Replace.Path)
+	}
+
+	dir := path.Join(src.Dir, dep.Replace.Path)
+	if dir == ".." || strings.HasPrefix(dir, "../") {
+		return nil, fmt.Errorf(
+			"replace path %q is outside of the repository",
+			dep.Replace.Path)
+	}
+
+	if dir == "." {
+		dir = ""
+	}
+
+	return untaggedDep(depMod, dir, src.Commit)
+}
+
+// untaggedDep reads the tree of a directory at a commit and finds the version
+// of depMod to link to. Commits with a version tag are treated as that
+// version.
+func untaggedDep(
+	depMod *Module, dir string, commit *object.Commit,
+) (*resolvedDep, error) {
+	// Look the directory up through a module that lives at the
+	// directory, so that Module.Tree can be reused.
+	tree, err := (&Module{Dir: dir}).Tree(commit)
+	if errors.Is(err, object.ErrDirectoryNotFound) {
+		return nil, fmt.Errorf("directory %q doesn't exist at commit %s",
+			dir, commit.Hash)
+	} else if err != nil {
+		return nil, fmt.Errorf("get tree of commit %s: %w", commit.Hash, err)
+	}
+
+	if tagged := VersionsAtCommit(commit.Hash, depMod.Versions); len(tagged) > 0 {
+		return &resolvedDep{
+			Module:  depMod,
+			Tree:    tree,
+			Dir:     dir,
+			Commit:  commit,
+			Version: tagged[0],
+		}, nil
+	}
+
+	return &resolvedDep{
+		Module:         depMod,
+		Tree:           tree,
+		Dir:            dir,
+		Commit:         commit,
+		Version:        closestTaggedVersion(depMod, commit),
+		UntaggedCommit: commit.Hash.String(),
+	}, nil
+}
+
+// closestTaggedVersion returns the newest version of the module that was
+// committed before the commit, falling back to the latest version.
+func closestTaggedVersion(mod *Module, commit *object.Commit) *ModuleVersion {
+	when := commit.Committer.When
+
+	// Versions are sorted in descending order.
+	for _, v := range mod.Versions {
+		if !v.Commit.Committer.When.After(when) {
+			return v
+		}
+	}
+
+	return mod.LatestVersion
+}
@@ -0,0 +1,669 @@
+package elephantdocs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ttab/newsdoc"
+	"github.com/ttab/revisor"
+)
+
+// DocumentExamples are example documents generated from the constraints of a
+// document type.
+type DocumentExamples struct {
+	// Minimal only has the required attributes, data and blocks.
+	Minimal newsdoc.Document
+	// KitchenSink has every attribute, data key and block that isn't
+	// deprecated.
+	KitchenSink newsdoc.Document
+	// Problems are the validation errors of the examples. Invalid
+	// examples are kept so that they can be inspected.
+	Problems []string `json:",omitempty"`
+}
+
+// exampleTime is used for all timestamps in examples so that they are stable
+// between builds.
+var exampleTime = time.Date(2025, 3, 14, 9, 30, 0, 0, time.UTC)
+
+// patternCandidates are tried in order for values that must match a pattern
+// that the generated value doesn't match.
+var patternCandidates = []string{
+	"example", "1", "0 0", "0 0 0 0", "1.5", "en", "sv-se",
+}
+
+// addDocumentExamples generates the examples for each document type from
+// the same schemas resolved with narrowBlockExtension, and validates them
+// against the constraint sets. Validation errors are recorded as problems of
+// the examples.
+func addDocumentExamples(
+	doc *SchemaDoc, from *SchemaDoc, validator *revisor.Validator,
+) {
+	enums := make(map[string]EnumDoc, len(from.Enums))
+
+	for _, e := range from.Enums {
+		enums[e.ID] = e
+	}
+
+	for i := range from.Documents {
+		d := &from.Documents[i]
+
+		// Document types that are only extended have no declaration
+		// to validate against.
+		if d.DeclaredIn == "" {
+			continue
+		}
+
+		examples := DocumentExamples{
+			Minimal:     newExampleGenerator(d.Type, enums, false).document(d),
+			KitchenSink: newExampleGenerator(d.Type, enums, true).document(d),
+		}
+
+		err := validateExample(validator, &examples.Minimal)
+		if err != nil {
+			examples.Problems = append(examples.Problems,
+				fmt.Sprintf("minimal example: %v", err))
+		}
+
+		err = validateExample(validator, &examples.KitchenSink)
+		if err != nil {
+			examples.Problems = append(examples.Problems,
+				fmt.Sprintf("kitchen sink example: %v", err))
+		}
+
+		doc.Documents[i].Examples = &examples
+	}
+}
+
+// exampleProblems returns the example problems of all document types.
+func exampleProblems(doc *SchemaDoc) error {
+	var errs []error
+
+	for _, d := range doc.Documents {
+		if d.Examples == nil {
+			continue
+		}
+
+		for _, p := range d.Examples.Problems {
+			errs = append(errs, fmt.Errorf("%q %s", d.Type, p))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// narrowBlockExtension merges a match extension into the block that it
+// targets like mergeBlockExtension, but also narrows the constraints of the
+// attributes and data keys that the block already declares, and applies the
+// count constraints of the extension. Revisor validates blocks against both
+// the block and its extensions, so examples have to satisfy both. The
+// documentation shows the constraints as declared.
+func narrowBlockExtension(dst *revisor.BlockConstraint, ext revisor.BlockConstraint) {
+	dst.Meta = append(dst.Meta, ext.Meta...)
+	dst.Links = append(dst.Links, ext.Links...)
+	dst.Content = append(dst.Content, ext.Content...)
+	narrowConstraintMap(&dst.Attributes, ext.Attributes)
+	narrowConstraintMap(&dst.Data, ext.Data)
+	mergeBlockCounts(dst, ext)
+}
+
+// narrowConstraintMap adds keys from src into dst that don't already exist,
+// and narrows the constraints of the keys that do. The map is copied as block
+// definitions are shared between the documents that reference them.
+func narrowConstraintMap(dst *revisor.ConstraintMap, src revisor.ConstraintMap) {
+	if len(src.Keys) == 0 {
+		return
+	}
+
+	*dst = dst.Copy()
+
+	for _, k := range src.Keys {
+		existing, exists := dst.Constraints[k]
+		if !exists {
+			dst.Keys = append(dst.Keys, k)
+			dst.Constraints[k] = src.Constraints[k]
+
+			continue
+		}
+
+		dst.Constraints[k] = narrowStringConstraint(existing, src.Constraints[k])
+	}
+}
+
+// narrowStringConstraint adds the value constraints of an extension to the
+// ones that the base constraint doesn't set.
+func narrowStringConstraint(
+	base revisor.StringConstraint, ext revisor.StringConstraint,
+) revisor.StringConstraint {
+	base.Optional = base.Optional && ext.Optional
+	base.AllowEmpty = base.AllowEmpty && ext.AllowEmpty
+
+	if base.Const == nil {
+		base.Const = ext.Const
+	}
+
+	if len(base.Enum) == 0 {
+		base.Enum = ext.Enum
+	}
+
+	if base.EnumRef == "" {
+		base.EnumRef = ext.EnumRef
+	}
+
+	if base.Pattern == nil {
+		base.Pattern = ext.Pattern
+	}
+
+	if len(base.Glob) == 0 {
+		base.Glob = ext.Glob
+	}
+
+	if base.Format == revisor.StringFormatNone {
+		base.Format = ext.Format
+	}
+
+	if base.Time == "" {
+		base.Time = ext.Time
+	}
+
+	if base.Geometry == "" {
+		base.Geometry = ext.Geometry
+	}
+
+	if base.HTMLPolicy == "" {
+		base.HTMLPolicy = ext.HTMLPolicy
+	}
+
+	return base
+}
+
+// mergeBlockCounts applies the count constraints of a match extension to the
+// block it targets, keeping the stricter of the limits.
+func mergeBlockCounts(dst *revisor.BlockConstraint, src revisor.BlockConstraint) {
+	if dst.Count == nil {
+		dst.Count = src.Count
+	}
+
+	if src.MinCount != nil && (dst.MinCount == nil || *src.MinCount > *dst.MinCount) {
+		dst.MinCount = src.MinCount
+	}
+
+	if src.MaxCount != nil && (dst.MaxCount == nil || *src.MaxCount < *dst.MaxCount) {
+		dst.MaxCount = src.MaxCount
+	}
+}
+
+func validateExample(validator *revisor.Validator, doc *newsdoc.Document) error {
+	results, err := validator.ValidateDocument(context.Background(), doc)
+	if err != nil {
+		return fmt.Errorf("validate document: %w", err)
+	}
+
+	if len(results) == 0 {
+		return nil
+	}
+
+	problems := make([]string, len(results))
+
+	for i, r := range results {
+		problems[i] = r.String()
+	}
+
+	return fmt.Errorf("invalid document: %s", strings.Join(problems, "; "))
+}
+
+// exampleJSON formats an example document for display.
+func exampleJSON(doc newsdoc.Document) (string, error) {
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("marshal example: %w", err)
+	}
+
+	return string(data), nil
+}
+
+type exampleGenerator struct {
+	docType     string
+	enums       map[string]EnumDoc
+	kitchenSink bool
+	uuids       int
+}
+
+func newExampleGenerator(
+	docType string, enums map[string]EnumDoc, kitchenSink bool,
+) *exampleGenerator {
+	return &exampleGenerator{
+		docType:     docType,
+		enums:       enums,
+		kitchenSink: kitchenSink,
+	}
+}
+
+func (g *exampleGenerator) document(d *DocumentDoc) newsdoc.Document {
+	doc := newsdoc.Document{
+		UUID: g.uuid(),
+		Type: d.Type,
+	}
+
+	for k, v := range g.values(d.Attributes, true) {
+		switch k {
+		case "uuid":
+			doc.UUID = v
+		case "uri":
+			doc.URI = v
+		case "url":
+			doc.URL = v
+		case "title":
+			doc.Title = v
+		case "language":
+			doc.Language = v
+		}
+	}
+
+	doc.Meta = g.blockList(resolvedConstraints(d.Meta))
+	doc.Links = g.blockList(resolvedConstraints(d.Links))
+	doc.Content = g.blockList(resolvedConstraints(d.Content))
+
+	return doc
+}
+
+func resolvedConstraints(blocks []ResolvedBlock) []revisor.BlockConstraint {
+	constraints := make([]revisor.BlockConstraint, len(blocks))
+
+	for i := range blocks {
+		constraints[i] = blocks[i].Block
+	}
+
+	return constraints
+}
+
+func nestedConstraints(blocks []*revisor.BlockConstraint) []revisor.BlockConstraint {
+	var constraints []revisor.BlockConstraint
+
+	for _, b := range blocks {
+		if b != nil {
+			constraints = append(constraints, *b)
+		}
+	}
+
+	return constraints
+}
+
+// blockList generates the blocks for a list of block constraints. Only
+// declarations generate blocks, match constraints are applied to the
+// generated blocks that they match.
+func (g *exampleGenerator) blockList(constraints []revisor.BlockConstraint) []newsdoc.Block {
+	var (
+		blocks     []newsdoc.Block
+		extensions []revisor.BlockConstraint
+	)
+
+	for _, bc := range constraints {
+		if bc.Declares == nil && len(bc.Match.Keys) > 0 {
+			extensions = append(extensions, bc)
+		}
+	}
+
+	for _, bc := range constraints {
+		if bc.Declares == nil {
+			continue
+		}
+
+		for range g.count(bc) {
+			blocks = append(blocks, g.block(bc, extensions))
+		}
+	}
+
+	return blocks
+}
+
+// count returns the number of blocks that the example should have for a
+// block constraint.
+func (g *exampleGenerator) count(bc revisor.BlockConstraint) int {
+	var count int
+
+	switch {
+	case bc.Count != nil:
+		count = *bc.Count
+	case bc.MinCount != nil:
+		count = *bc.MinCount
+	}
+
+	if g.kitchenSink && bc.Count == nil && bc.Deprecated == nil {
+		count = max(count, 1)
+
+		if bc.MaxCount != nil {
+			count = min(count, *bc.MaxCount)
+		}
+	}
+
+	return count
+}
+
+func (g *exampleGenerator) block(
+	bc revisor.BlockConstraint, extensions []revisor.BlockConstraint,
+) newsdoc.Block {
+	attributes := bc.Match.Copy()
+	data := bc.Data.Copy()
+
+	mergeConstraintMap(&attributes, bc.Attributes)
+
+	b := g.newBlock(bc, attributes, data)
+
+	var extended bool
+
+	for _, ext := range extensions {
+		if !exampleMatches(ext.Match, b) {
+			continue
+		}
+
+		narrowConstraintMap(&attributes, ext.Match)
+		narrowConstraintMap(&attributes, ext.Attributes)
+		narrowConstraintMap(&data, ext.Data)
+
+		extended = true
+	}
+
+	if extended {
+		b = g.newBlock(bc, attributes, data)
+	}
+
+	return b
+}
+
+func (g *exampleGenerator) newBlock(
+	bc revisor.BlockConstraint, attributes revisor.ConstraintMap,
+	data revisor.ConstraintMap,
+) newsdoc.Block {
+	b := newsdoc.Block{
+		Type: bc.Declares.Type,
+		Rel:  bc.Declares.Rel,
+		Role: bc.Declares.Role,
+	}
+
+	for k, v := range g.values(attributes, true) {
+		switch k {
+		case "id":
+			b.ID = v
+		case "uuid":
+			b.UUID = v
+		case "type":
+			b.Type = v
+		case "uri":
+			b.URI = v
+		case "url":
+			b.URL = v
+		case "title":
+			b.Title = v
+		case "rel":
+			b.Rel = v
+		case "role":
+			b.Role = v
+		case "name":
+			b.Name = v
+		case "value":
+			b.Value = v
+		case "contenttype":
+			b.Contenttype = v
+		case "sensitivity":
+			b.Sensitivity = v
+		}
+	}
+
+	if values := g.values(data, false); len(values) > 0 {
+		b.Data = values
+	}
+
+	b.Meta = g.blockList(nestedConstraints(bc.Meta))
+	b.Links = g.blockList(nestedConstraints(bc.Links))
+	b.Content = g.blockList(nestedConstraints(bc.Content))
+
+	return b
+}
+
+// exampleMatches checks if a generated block matches the const and enum
+// constraints of a match constraint map.
+func exampleMatches(match revisor.ConstraintMap, b newsdoc.Block) bool {
+	values := map[string]string{
+		"id": b.ID, "uuid": b.UUID, "type": b.Type, "uri": b.URI,
+		"url": b.URL, "title": b.Title, "rel": b.Rel, "role": b.Role,
+		"name": b.Name, "value": b.Value, "contenttype": b.Contenttype,
+		"sensitivity": b.Sensitivity,
+	}
+
+	for _, k := range match.Keys {
+		sc := match.Constraints[k]
+		v := values[k]
+
+		switch {
+		case sc.Const != nil && *sc.Const != v:
+			return false
+		case len(sc.Enum) > 0 && !slices.Contains(sc.Enum, v):
+			return false
+		}
+	}
+
+	return true
+}
+
+// values generates values for the required keys of a constraint map, or for
+// all keys that aren't deprecated in a kitchen sink example. Attributes always
+// exist, so they can only be left out if they are allowed to be empty.
+func (g *exampleGenerator) values(
+	m revisor.ConstraintMap, attributes bool,
+) map[string]string {
+	values := make(map[string]string, len(m.Keys))
+
+	for _, k := range m.Keys {
+		sc := m.Constraints[k]
+
+		optional := sc.Optional
+		if attributes {
+			optional = sc.AllowEmpty
+		}
+
+		if optional && (!g.kitchenSink || sc.Deprecated != nil) {
+			continue
+		}
+
+		v, ok := g.value(k, sc)
+		if !ok && optional {
+			continue
+		}
+
+		values[k] = v
+	}
+
+	return values
+}
+
+// value generates a value for a string constraint. It returns false if no
+// value could be found that satisfies the constraint.
+func (g *exampleGenerator) value(key string, sc revisor.StringConstraint) (string, bool) {
+	var v string
+
+	switch {
+	case sc.Const != nil:
+		return *sc.Const, true
+	case len(sc.Enum) > 0:
+		return sc.Enum[0], true
+	case sc.EnumRef != "":
+		return g.enumValue(sc.EnumRef)
+	case len(sc.Glob) > 0:
+		v = globExample(sc.Glob)
+	case sc.Time != "":
+		v = exampleTime.Format(sc.Time)
+	case sc.Format != revisor.StringFormatNone:
+		v = formatExample(sc)
+	default:
+		v = keyExample(g, key)
+	}
+
+	if sc.Pattern == nil || sc.Pattern.Match(v) {
+		return v, true
+	}
+
+	for _, c := range patternCandidates {
+		if sc.Pattern.Match(c) {
+			return c, true
+		}
+	}
+
+	return v, false
+}
+
+func (g *exampleGenerator) enumValue(id string) (string, bool) {
+	e, ok := g.enums[id]
+	if !ok {
+		return "", false
+	}
+
+	for _, v := range e.Values {
+		if !v.Forbidden && v.Deprecated == nil {
+			return v.Value, true
+		}
+	}
+
+	return "", false
+}
+
+// uuid returns a UUID that is stable for the document type and position.
+func (g *exampleGenerator) uuid() string {
+	g.uuids++
+
+	name := g.docType + "#" + strconv.Itoa(g.uuids)
+
+	return uuid.NewSHA1(uuid.NameSpaceURL, []byte(name)).String()
+}
+
+func keyExample(g *exampleGenerator, key string) string {
+	switch key {
+	case "uuid":
+		return g.uuid()
+	case "uri":
+		return "example://" + g.docType + "/1"
+	case "url":
+		return "https://example.com/"
+	case "language":
+		return "en"
+	case "contenttype":
+		return "text/plain"
+	}
+
+	return "Example " + key
+}
+
+func formatExample(sc revisor.StringConstraint) string {
+	switch sc.Format {
+	case revisor.StringFormatRFC3339:
+		return exampleTime.Format(time.RFC3339)
+	case revisor.StringFormatInt:
+		return "1"
+	case revisor.StringFormatFloat:
+		return "1.5"
+	case revisor.StringFormatBoolean:
+		return "true"
+	case revisor.StringFormatUUID:
+		return uuid.NewSHA1(uuid.NameSpaceURL, []byte("example")).String()
+	case revisor.StringFormatWKT:
+		return wktExample(sc.Geometry)
+	case revisor.StringFormatColour:
+		return colourExample(sc.ColourFormats)
+	case revisor.StringFormatHTML:
+		return "Example text"
+	}
+
+	return "example"
+}
+
+func colourExample(formats []revisor.ColourFormat) string {
+	format := revisor.ColourRGB
+
+	if len(formats) > 0 {
+		format = formats[0]
+	}
+
+	switch format {
+	case revisor.ColourHex:
+		return "#1e90ff"
+	case revisor.ColourRGBA:
+		return "rgba(30, 144, 255, 0.5)"
+	}
+
+	return "rgb(30, 144, 255)"
+}
+
+// wktExample generates a geometry for a geometry spec like "point" or
+// "polygon-z".
+func wktExample(spec string) string {
+	shape, coord, _ := strings.Cut(spec, "-")
+
+	points := []string{"18.07 59.33", "18.08 59.34", "18.06 59.35"}
+
+	for i := range points {
+		switch coord {
+		case "z":
+			points[i] += " 10"
+		case "m":
+			points[i] += " 1"
+		case "zm":
+			points[i] += " 10 1"
+		}
+	}
+
+	line := strings.Join(points, ", ")
+	ring := line + ", " + points[0]
+
+	var body string
+
+	switch revisor.Geometry(shape) {
+	case revisor.GeometryMultiPoint:
+		body = "((" + points[0] + "), (" + points[1] + "))"
+	case revisor.GeometryLineString, revisor.GeometryCircularString:
+		body = "(" + line + ")"
+	case revisor.GeometryMultiLineString:
+		body = "((" + line + "))"
+	case revisor.GeometryPolygon:
+		body = "((" + ring + "))"
+	case revisor.GeometryMultiPolygon:
+		body = "(((" + ring + ")))"
+	default:
+		shape = string(revisor.GeometryPoint)
+		body = "(" + points[0] + ")"
+	}
+
+	name := strings.ToUpper(shape)
+	if coord != "" {
+		name += " " + strings.ToUpper(coord)
+	}
+
+	return name + " " + body
+}
+
+var globWildcards = regexp.MustCompile(`\*\*|\*|\?|\{([^,}]*)[^}]*\}|\[(.)[^\]]*\]`)
+
+// globExample generates a value matching the first glob pattern.
+func globExample(globs revisor.GlobList) string {
+	pattern := globPattern(globs[0])
+	if pattern == "" {
+		return "example"
+	}
+
+	return globWildcards.ReplaceAllStringFunc(pattern, func(m string) string {
+		switch {
+		case m == "*" || m == "**":
+			return "example"
+		case m == "?":
+			return "x"
+		}
+
+		return globWildcards.ReplaceAllString(m, "$1$2")
+	})
+}
@@ -0,0 +1,276 @@
+package elephantdocs
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+
+	"github.com/ttab/revisor"
+)
+
+func mustConstraintSet(t *testing.T, data string) revisor.ConstraintSet {
+	t.Helper()
+
+	var cs revisor.ConstraintSet
+
+	err := json.Unmarshal([]byte(data), &cs)
+	if err != nil {
+		t.Fatalf("unmarshal constraint set: %v", err)
+	}
+
+	return cs
+}
+
+const extendedBlockSet = `{
+  "version": 1,
+  "name": "test",
+  "documents": [
+    {
+      "declares": "test/doc",
+      "meta": [
+        {
+          "declares": {"type": "test/meta"},
+          "maxCount": 3,
+          "attributes": {
+            "title": {"optional": true}
+          }
+        },
+        {
+          "match": {"type": {"const": "test/meta"}},
+          "count": 1,
+          "attributes": {
+            "title": {"enum": ["a", "b"]}
+          },
+          "data": {
+            "note": {}
+          }
+        }
+      ]
+    }
+  ]
+}`
+
+func TestResolveSchemasMergeBlockExtension(t *testing.T) {
+	sets := []revisor.ConstraintSet{mustConstraintSet(t, extendedBlockSet)}
+	conf := SchemaGroupConfig{Sets: []SchemaSetConfig{{Name: "test"}}}
+
+	doc, err := resolveSchemas(sets, conf, mergeBlockExtension)
+	if err != nil {
+		t.Fatalf("resolve schemas: %v", err)
+	}
+
+	meta := doc.Documents[0].Meta
+	if len(meta) != 1 {
+		t.Fatalf("got %d meta blocks, want the extension to be merged", len(meta))
+	}
+
+	bc := meta[0].Block
+
+	// The documentation shows the constraints of the block as declared,
+	// with the data keys that the extension adds.
+	title := bc.Attributes.Constraints["title"]
+	if !title.Optional || len(title.Enum) != 0 {
+		t.Errorf("got title constraint %+v, want it as declared", title)
+	}
+
+	if bc.Count != nil || bc.MaxCount == nil || *bc.MaxCount != 3 {
+		t.Errorf("got count %v and max count %v, want the declared counts",
+			bc.Count, bc.MaxCount)
+	}
+
+	if !slices.Contains(bc.Data.Keys, "note") {
+		t.Errorf("got data keys %q, want the extension data", bc.Data.Keys)
+	}
+}
+
+func TestResolveSchemasNarrowBlockExtension(t *testing.T) {
+	sets := []revisor.ConstraintSet{mustConstraintSet(t, extendedBlockSet)}
+	conf := SchemaGroupConfig{Sets: []SchemaSetConfig{{Name: "test"}}}
+
+	doc, err := resolveSchemas(sets, conf, narrowBlockExtension)
+	if err != nil {
+		t.Fatalf("resolve schemas: %v", err)
+	}
+
+	bc := doc.Documents[0].Meta[0].Block
+
+	title := bc.Attributes.Constraints["title"]
+	if title.Optional || !slices.Equal(title.Enum, []string{"a", "b"}) {
+		t.Errorf("got title constraint %+v, want it narrowed by the extension",
+			title)
+	}
+
+	if bc.Count == nil || *bc.Count != 1 {
+		t.Errorf("got count %v, want the count of the extension", bc.Count)
+	}
+
+	// The declared constraints must be left as they were.
+	declared := sets[0].Documents[0].Meta[0].Attributes.Constraints["title"]
+	if len(declared.Enum) != 0 {
+		t.Errorf("the declared title constraint was modified: %+v", declared)
+	}
+}
+
+func TestNarrowConstraintMapCopies(t *testing.T) {
+	shared := revisor.ConstraintMap{
+		Keys: []string{"role"},
+		Constraints: map[string]revisor.StringConstraint{
+			"role": {AllowEmpty: true},
+		},
+	}
+
+	narrowed := shared
+
+	narrowConstraintMap(&narrowed, revisor.ConstraintMap{
+		Keys: []string{"role", "value"},
+		Constraints: map[string]revisor.StringConstraint{
+			"role":  {Enum: []string{"x"}},
+			"value": {},
+		},
+	})
+
+	role := narrowed.Constraints["role"]
+	if role.AllowEmpty || !slices.Equal(role.Enum, []string{"x"}) {
+		t.Errorf("got role constraint %+v, want it narrowed", role)
+	}
+
+	if !slices.Equal(narrowed.Keys, []string{"role", "value"}) {
+		t.Errorf("got keys %q", narrowed.Keys)
+	}
+
+	if len(shared.Keys) != 1 || len(shared.Constraints["role"].Enum) != 0 {
+		t.Errorf("the shared constraint map was modified: %+v", shared)
+	}
+}
+
+func TestAddDocumentExamplesRecordsProblems(t *testing.T) {
+	cs := mustConstraintSet(t, `{
+  "version": 1,
+  "name": "test",
+  "documents": [
+    {
+      "declares": "test/valid"
+    },
+    {
+      "declares": "test/invalid",
+      "attributes": {
+        "title": {"pattern": "^[q]{7}$"}
+      }
+    }
+  ]
+}`)
+
+	sets := []revisor.ConstraintSet{cs}
+	conf := SchemaGroupConfig{Sets: []SchemaSetConfig{{Name: "test"}}}
+
+	doc, err := resolveSchemas(sets, conf, narrowBlockExtension)
+	if err != nil {
+		t.Fatalf("resolve schemas: %v", err)
+	}
+
+	validator, err := revisor.NewValidator(cs)
+	if err != nil {
+		t.Fatalf("create validator: %v", err)
+	}
+
+	addDocumentExamples(doc, doc, validator)
+
+	for _, d := range doc.Documents {
+		if d.Examples == nil {
+			t.Fatalf("no examples for %q", d.Type)
+		}
+
+		switch d.Type {
+		case "test/valid":
+			if len(d.Examples.Problems) != 0 {
+				t.Errorf("got problems for a valid document: %q",
+					d.Examples.Problems)
+			}
+		case "test/invalid":
+			if len(d.Examples.Problems) == 0 {
+				t.Error("expected problems for the invalid examples")
+			}
+		}
+	}
+
+	if exampleProblems(doc) == nil {
+		t.Error("expected the problems to be reported for the version")
+	}
+}
+
+const nestedExtensionSet = `{
+  "version": 1,
+  "name": "test",
+  "documents": [
+    {
+      "declares": "test/doc",
+      "attributes": {"title": {}},
+      "meta": [{"ref": "test/meta"}]
+    },
+    {
+      "match": {"type": {"const": "test/doc"}},
+      "attributes": {"url": {}}
+    }
+  ],
+  "meta": [
+    {
+      "id": "test/meta",
+      "block": {
+        "declares": {"type": "test/meta"},
+        "attributes": {"title": {}},
+        "meta": [
+          {
+            "declares": {"type": "test/nested"},
+            "attributes": {"value": {}}
+          },
+          {
+            "match": {"type": {"const": "test/nested"}},
+            "attributes": {"value": {"enum": ["a"]}, "extra": {}},
+            "data": {"note": {}}
+          }
+        ]
+      }
+    },
+    {
+      "id": "test/meta-extension",
+      "block": {
+        "match": {"type": {"const": "test/meta"}},
+        "attributes": {"role": {}}
+      }
+    }
+  ]
+}`
+
+func TestResolveSchemasLeavesCopiedSetsUnchanged(t *testing.T) {
+	sets := []revisor.ConstraintSet{
+		mustConstraintSet(t, extendedBlockSet),
+		mustConstraintSet(t, nestedExtensionSet),
+	}
+	conf := SchemaGroupConfig{Sets: []SchemaSetConfig{
+		{Name: "extended"}, {Name: "nested"},
+	}}
+
+	before, err := json.Marshal(sets)
+	if err != nil {
+		t.Fatalf("marshal constraint sets: %v", err)
+	}
+
+	for _, merge := range []blockExtensionMerge{
+		mergeBlockExtension, narrowBlockExtension,
+	} {
+		_, err := resolveSchemas(copyConstraintSets(sets), conf, merge)
+		if err != nil {
+			t.Fatalf("resolve schemas: %v", err)
+		}
+	}
+
+	after, err := json.Marshal(sets)
+	if err != nil {
+		t.Fatalf("marshal constraint sets: %v", err)
+	}
+
+	if string(before) != string(after) {
+		t.Errorf("resolving the schemas changed the constraint sets:\n%s\n%s",
+			before, after)
+	}
+}
@@ -0,0 +1,312 @@
+package elephantdocs
+
+import (
+	"encoding/xml"
+	"fmt"
+	"html"
+	"html/template"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ttab/elephant-docs/internal"
+)
+
+// Feed paths relative to the site root.
+const (
+	WhatsNewFeedPath = "/feed.xml"
+	SchemaFeedPath   = "/schemas/feed.xml"
+)
+
+// APIFeedPath returns the path of the feed for an API.
+func APIFeedPath(api string) string {
+	return fmt.Sprintf("/apis/%s/feed.xml", api)
+}
+
+// FeedLink is an alternate feed link for a page.
+type FeedLink struct {
+	Title string
+	HRef  string
+}
+
+// FeedEntry is a release listed in a feed and on the "what's new" page.
+type FeedEntry struct {
+	// Source is the title of the API or schema group.
+	Source       string
+	Version      string
+	HRef         string
+	Date         time.Time
+	IsPrerelease bool
+	// Author is the author of the tagged commit.
+	Author string
+	// Content is a HTML description of the release.
+	Content template.HTML
+}
+
+func (e FeedEntry) Title() string {
+	return e.Source + " " + e.Version
+}
+
+// WhatsNewPage lists the releases of all APIs and schemas.
+type WhatsNewPage struct {
+	Entries []FeedEntry
+}
+
+// apiFeedEntries creates feed entries for the versions of an API as returned
+// by getChangelog. Unreleased changes are left out.
+func apiFeedEntries(title string, api string, versions []*ModuleVersion) []FeedEntry {
+	entries := make([]FeedEntry, 0, len(versions))
+
+	for _, v := range versions {
+		if v.IsUnreleased {
+			continue
+		}
+
+		entries = append(entries, FeedEntry{
+			Source:       title,
+			Version:      v.Tag,
+			HRef:         fmt.Sprintf("/apis/%s/%s", api, v.Tag),
+			Date:         versionDate(v),
+			IsPrerelease: v.IsPrerelease,
+			Author:       v.Commit.Author.Name,
+			Content:      releaseContent(v),
+		})
+	}
+
+	return entries
+}
+
+// schemaFeedEntries creates feed entries for the tags of the schema
+// repository.
+func schemaFeedEntries(title string, versions []*ModuleVersion) []FeedEntry {
+	entries := make([]FeedEntry, 0, len(versions))
+
+	for _, v := range versions {
+		entries = append(entries, FeedEntry{
+			Source:       title,
+			Version:      v.Tag,
+			HRef:         "/schemas/" + v.Tag,
+			Date:         versionDate(v),
+			IsPrerelease: v.IsPrerelease,
+			Author:       v.Commit.Author.Name,
+			Content:      releaseContent(v),
+		})
+	}
+
+	return entries
+}
+
+func versionDate(v *ModuleVersion) time.Time {
+	if !v.Tagged.IsZero() {
+		return v.Tagged
+	}
+
+	return v.Commit.Committer.When
+}
+
+// releaseContent describes a release using its release notes and grouped
+// changelog.
+func releaseContent(v *ModuleVersion) template.HTML {
+	var b strings.Builder
+
+	if v.ReleaseNotes != "" {
+		b.WriteString("<p>")
+		b.WriteString(strings.ReplaceAll(
+			html.EscapeString(v.ReleaseNotes), "\n", "<br/>"))
+		b.WriteString("</p>")
+	}
+
+	for _, g := range v.Groups {
+		fmt.Fprintf(&b, "<h4>%s</h4><ul>", html.EscapeString(g.Title))
+
+		for _, e := range g.Entries {
+			fmt.Fprintf(&b, "<li>%s</li>", html.EscapeString(e.Subject))
+		}
+
+		b.WriteString("</ul>")
+	}
+
+	return template.HTML(b.String())
+}
+
+// sortFeedEntries sorts entries with the newest first.
+func sortFeedEntries(entries []FeedEntry) {
+	slices.SortStableFunc(entries, func(a, b FeedEntry) int {
+		return b.Date.Compare(a.Date)
+	})
+}
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Author  *atomPerson `xml:"author"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomPerson struct {
+	Name string `xml:"name"`
+}
+
+type atomLink struct {
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+	Href string `xml:"href,attr"`
+}
+
+type atomEntry struct {
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Author  *atomPerson `xml:"author"`
+	Links   []atomLink  `xml:"link"`
+	Content atomContent `xml:"content"`
+}
+
+type atomContent struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+// feedWriter writes Atom feeds with links resolved against the site URL.
+type feedWriter struct {
+	outDir  string
+	siteURL *url.URL
+	author  string
+}
+
+// newFeedWriter creates a feed writer for the public URL of the site. Atom
+// requires absolute IDs, so the site URL must be absolute, and no feeds are
+// written if it isn't set. The author is the author of the feeds, entries are
+// attributed to the authors of the tagged commits.
+func newFeedWriter(outDir string, siteURL string, author string) (*feedWriter, error) {
+	w := feedWriter{
+		outDir: outDir,
+		author: author,
+	}
+
+	if siteURL == "" {
+		return &w, nil
+	}
+
+	u, err := url.Parse(siteURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid site URL: %w", err)
+	}
+
+	if !u.IsAbs() || u.Host == "" {
+		return nil, fmt.Errorf("site URL %q isn't absolute", siteURL)
+	}
+
+	w.siteURL = u
+
+	return &w, nil
+}
+
+// Enabled reports whether feeds are written, which requires a site URL.
+func (w *feedWriter) Enabled() bool {
+	return w.siteURL != nil
+}
+
+// URL returns the absolute URL of a site path.
+func (w *feedWriter) URL(sitePath string) string {
+	return w.siteURL.JoinPath(sitePath).String()
+}
+
+// Write writes a feed to the site path, unless feeds are disabled.
+func (w *feedWriter) Write(
+	sitePath string, title string, htmlPath string, entries []FeedEntry,
+) (outErr error) {
+	if !w.Enabled() {
+		return nil
+	}
+
+	feed := atomFeed{
+		ID:     w.URL(sitePath),
+		Title:  title,
+		Author: feedAuthor(w.author),
+		Links: []atomLink{
+			{Rel: "self", Type: "application/atom+xml", Href: w.URL(sitePath)},
+			{Rel: "alternate", Type: "text/html", Href: w.URL(htmlPath)},
+		},
+	}
+
+	updated := time.Unix(0, 0)
+
+	// Atom requires an author for the feed unless all entries have one,
+	// so fall back to the feed title for empty feeds.
+	if feed.Author == nil && len(entries) == 0 {
+		feed.Author = feedAuthor(title)
+	}
+
+	for _, e := range entries {
+		if e.Date.After(updated) {
+			updated = e.Date
+		}
+
+		author := feedAuthor(e.Author)
+		if author == nil && feed.Author == nil {
+			author = feedAuthor(e.Source)
+		}
+
+		feed.Entries = append(feed.Entries, atomEntry{
+			// The same page can be listed for several versions,
+			// so the version is part of the ID.
+			ID:      w.URL(e.HRef) + "#" + url.PathEscape(e.Version),
+			Title:   e.Title(),
+			Updated: e.Date.UTC().Format(time.RFC3339),
+			Author:  author,
+			Links: []atomLink{
+				{Rel: "alternate", Href: w.URL(e.HRef)},
+			},
+			Content: atomContent{
+				Type: "html",
+				Body: string(e.Content),
+			},
+		})
+	}
+
+	feed.Updated = updated.UTC().Format(time.RFC3339)
+
+	outPath := filepath.Join(w.outDir, filepath.FromSlash(sitePath))
+
+	err := os.MkdirAll(filepath.Dir(outPath), 0o770)
+	if err != nil {
+		return fmt.Errorf("create feed directory: %w", err)
+	}
+
+	f, err := os.Create(outPath)
+	if err != nil {
+		return fmt.Errorf("create feed file: %w", err)
+	}
+
+	defer internal.Close("feed file", f, &outErr)
+
+	_, err = f.WriteString(xml.Header)
+	if err != nil {
+		return fmt.Errorf("write feed: %w", err)
+	}
+
+	enc := xml.NewEncoder(f)
+	enc.Indent("", "  ")
+
+	err = enc.Encode(feed)
+	if err != nil {
+		return fmt.Errorf("encode feed: %w", err)
+	}
+
+	return nil
+}
+
+func feedAuthor(name string) *atomPerson {
+	if name == "" {
+		return nil
+	}
+
+	return &atomPerson{Name: name}
+}
@@ -0,0 +1,150 @@
+package elephantdocs
+
+import (
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewFeedWriterRequiresAbsoluteURL(t *testing.T) {
+	for _, siteURL := range []string{"/elephant", "docs.example.com"} {
+		_, err := newFeedWriter(t.TempDir(), siteURL, "")
+		if err == nil {
+			t.Errorf("expected site URL %q to be rejected", siteURL)
+		}
+	}
+}
+
+func TestFeedWriterWithoutSiteURL(t *testing.T) {
+	dir := t.TempDir()
+
+	w, err := newFeedWriter(dir, "", "")
+	if err != nil {
+		t.Fatalf("create feed writer: %v", err)
+	}
+
+	if w.Enabled() {
+		t.Error("feeds are enabled without a site URL")
+	}
+
+	err = w.Write(WhatsNewFeedPath, "What's new", "/whats-new", nil)
+	if err != nil {
+		t.Fatalf("write feed: %v", err)
+	}
+
+	_, err = os.Stat(filepath.Join(dir, "feed.xml"))
+	if !os.IsNotExist(err) {
+		t.Errorf("expected no feed to be written, got %v", err)
+	}
+}
+
+func TestFeedWriterWrite(t *testing.T) {
+	dir := t.TempDir()
+
+	w, err := newFeedWriter(dir, "https://docs.example.com/elephant", "")
+	if err != nil {
+		t.Fatalf("create feed writer: %v", err)
+	}
+
+	entries := []FeedEntry{
+		{
+			Source:  "Repository",
+			Version: "v1.0.0",
+			HRef:    "/apis/repository/v1.0.0",
+			Date:    time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC),
+			Author:  "Jane Doe",
+		},
+		{
+			Source:  "Repository",
+			Version: "v0.9.0",
+			HRef:    "/apis/repository/v0.9.0",
+			Date:    time.Date(2025, 2, 1, 12, 0, 0, 0, time.UTC),
+		},
+	}
+
+	err = w.Write(APIFeedPath("repository"), "Repository",
+		"/apis/repository/changelog", entries)
+	if err != nil {
+		t.Fatalf("write feed: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "apis", "repository", "feed.xml"))
+	if err != nil {
+		t.Fatalf("read feed: %v", err)
+	}
+
+	var feed atomFeed
+
+	err = xml.Unmarshal(data, &feed)
+	if err != nil {
+		t.Fatalf("parse feed: %v", err)
+	}
+
+	if feed.ID != "https://docs.example.com/elephant/apis/repository/feed.xml" {
+		t.Errorf("got feed ID %q", feed.ID)
+	}
+
+	for _, l := range feed.Links {
+		if !strings.HasPrefix(l.Href, "https://") {
+			t.Errorf("got relative %s link %q", l.Rel, l.Href)
+		}
+	}
+
+	if feed.Author != nil {
+		t.Errorf("got feed author %q without a configured author",
+			feed.Author.Name)
+	}
+
+	if len(feed.Entries) != 2 {
+		t.Fatalf("got %d entries, want 2", len(feed.Entries))
+	}
+
+	first, second := feed.Entries[0], feed.Entries[1]
+
+	if first.ID != "https://docs.example.com/elephant/apis/repository/v1.0.0#v1.0.0" {
+		t.Errorf("got entry ID %q", first.ID)
+	}
+
+	if first.Author == nil || first.Author.Name != "Jane Doe" {
+		t.Errorf("got entry author %v, want Jane Doe", first.Author)
+	}
+
+	// Entries without an author fall back to the source, as there's no
+	// feed author.
+	if second.Author == nil || second.Author.Name != "Repository" {
+		t.Errorf("got entry author %v, want Repository", second.Author)
+	}
+}
+
+func TestFeedWriterEmptyFeedAuthor(t *testing.T) {
+	dir := t.TempDir()
+
+	w, err := newFeedWriter(dir, "https://docs.example.com", "")
+	if err != nil {
+		t.Fatalf("create feed writer: %v", err)
+	}
+
+	err = w.Write(SchemaFeedPath, "Document Schemas", "/schemas", nil)
+	if err != nil {
+		t.Fatalf("write feed: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "schemas", "feed.xml"))
+	if err != nil {
+		t.Fatalf("read feed: %v", err)
+	}
+
+	var feed atomFeed
+
+	err = xml.Unmarshal(data, &feed)
+	if err != nil {
+		t.Fatalf("parse feed: %v", err)
+	}
+
+	if feed.Author == nil || feed.Author.Name != "Document Schemas" {
+		t.Errorf("got feed author %v, want the feed title", feed.Author)
+	}
+}
@@ -2,6 +2,7 @@ package elephantdocs
 
 import (
 	"bytes"
+	"cmp"
 	"context"
 	"embed"
 	"errors"
@@ -17,6 +18,7 @@ import (
 	"slices"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/Masterminds/semver/v3"
 	"github.com/go-git/go-git/v6"
@@ -28,6 +30,7 @@ import (
 	"github.com/yuin/goldmark"
 	highlighting "github.com/yuin/goldmark-highlighting/v2"
 	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
 	"golang.org/x/net/html"
 	"golang.org/x/sync/errgroup"
 )
@@ -46,6 +49,30 @@ type API struct {
 	LatestVersion string
 	Data          APIData
 	Readme        template.HTML
+	IsPrerelease  bool
+	// LatestIsPrerelease is set when the latest version of the module is a
+	// pre-release, either because it has no stable versions or because it
+	// follows the pre-release channel.
+	LatestIsPrerelease bool
+	// Branch is set for the unreleased channel.
+	Branch string `json:",omitempty"`
+	// HasUnreleased is set when the module has an unreleased channel.
+	HasUnreleased bool
+	// DocsNewerThanTag is set when the documentation has been read from a
+	// newer commit than the tagged version.
+	DocsNewerThanTag bool
+	// UntaggedCommit is set for dependencies that have been resolved to a
+	// commit that isn't a tagged version, Version is then the closest
+	// tagged version.
+	UntaggedCommit string `json:",omitempty"`
+	// BuiltAgainst lists the versions of the included modules that the
+	// version depends on.
+	BuiltAgainst []DependencyRef `json:",omitempty"`
+	// SourceURL links to the API in the source repository.
+	SourceURL string `json:",omitempty"`
+	// Lifecycle is set if the version is deprecated, end-of-life or
+	// yanked.
+	Lifecycle *LifecycleNotice `json:",omitempty"`
 }
 
 type APIData struct {
@@ -63,26 +90,85 @@ type MethodPage struct {
 	Response    MessageRef
 	Doc         []string
 	Readme      template.HTML
+	Lifecycle   *LifecycleNotice `json:",omitempty"`
 }
 
 type Module struct {
 	Title         string
 	Name          string
+	Source        string
+	Dir           string          `json:",omitempty"`
 	Repo          *git.Repository `json:"-"`
 	Versions      []*ModuleVersion
 	LatestVersion *ModuleVersion
+	Unreleased    *ModuleVersion
 	VersionLookup map[string]*ModuleVersion `json:"-"`
 	APIs          map[string]APIConfig
 	Include       map[string]IncludeConfig
+	HeadDocs      bool
+	Changelog     *ChangelogConfig `json:"-"`
+	Links         SourceLinks      `json:"-"`
+
+	// loadCommit reads the commit of a version on demand, it's set for
+	// modules that don't read all versions up front.
+	loadCommit func(v *ModuleVersion) error
+}
+
+// UnreleasedTag is used in place of a version tag for the unreleased channel.
+const UnreleasedTag = "unreleased"
+
+// versionCommit returns the commit of a version, reading it first if the
+// module loads versions on demand.
+func (m *Module) versionCommit(v *ModuleVersion) (*object.Commit, error) {
+	if m.loadCommit != nil {
+		err := m.loadCommit(v)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return v.Commit, nil
+}
+
+// Tree returns the tree of the module root at the given commit.
+func (m *Module) Tree(commit *object.Commit) (*object.Tree, error) {
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, fmt.Errorf("get commit tree: %w", err)
+	}
+
+	if m.Dir == "" {
+		return tree, nil
+	}
+
+	dir, err := tree.Tree(m.Dir)
+	if err != nil {
+		return nil, fmt.Errorf("get module directory %q: %w", m.Dir, err)
+	}
+
+	return dir, nil
 }
 
 type ModuleVersion struct {
 	Tag                string
+	TagName            string          `json:",omitempty"`
 	Commit             *object.Commit  `json:"-"`
 	Version            *semver.Version `json:"-"`
 	IsPrerelease       bool
+	IsUnreleased       bool
+	Branch             string `json:",omitempty"`
 	DependencyVersions map[string]string
-	Log                []*object.Commit `json:"-"`
+	// ReleaseNotes is the message of annotated version tags.
+	ReleaseNotes string `json:",omitempty"`
+	// Tagged is the date of the tag, or of the commit for lightweight
+	// tags.
+	Tagged time.Time        `json:",omitzero"`
+	Log    []*object.Commit `json:"-"`
+	// Groups is the changelog of the version grouped by the kind of
+	// change, without noise commits.
+	Groups []ChangelogGroup `json:",omitempty"`
+	// Lifecycle is set for deprecated, end-of-life and yanked versions.
+	Lifecycle *VersionLifecycle `json:",omitempty"`
 }
 
 func VersionsAtCommit(id plumbing.Hash, versions []*ModuleVersion) []*ModuleVersion {
@@ -99,7 +185,7 @@ func VersionsAtCommit(id plumbing.Hash, versions []*ModuleVersion) []*ModuleVers
 
 func Generate(
 	ctx context.Context, outDir string, basePath string, conf Config,
-	schemaPrerelease bool, uiPrintln func(format string, a ...any),
+	uiPrintln func(format string, a ...any),
 ) error {
 	apiConf := make(map[string]APIConfig)
 	modules := make(map[string]*Module)
@@ -118,6 +204,15 @@ func Generate(
 		return fmt.Errorf("invalid base path: %w", err)
 	}
 
+	feeds, err := newFeedWriter(outDir, conf.SiteURL, conf.FeedAuthor)
+	if err != nil {
+		return fmt.Errorf("create feed writer: %w", err)
+	}
+
+	if !feeds.Enabled() {
+		slog.Warn("site_url isn't set, skipping the Atom feeds")
+	}
+
 	tpl := template.New("templates")
 
 	funcs := template.FuncMap{
@@ -133,12 +228,19 @@ func Generate(
 
 			return template.HTML(strings.Join(lines, "<br/>"))
 		},
+		"changelog_entry_ctx": func(links SourceLinks, entry ChangelogEntry) map[string]any {
+			return map[string]any{
+				"Links": links,
+				"Entry": entry,
+			}
+		},
 		"attr": func(name string) template.HTMLAttr {
 			return template.HTMLAttr(name)
 		},
 		"base_path": func() string {
 			return basePath
 		},
+		"feeds_enabled": feeds.Enabled,
 		"abs_url": func(targetUrl string) string {
 			target, err := url.Parse(targetUrl)
 			if err != nil {
@@ -181,6 +283,11 @@ func Generate(
 		maps.Copy(apiConf, mod.APIs)
 	}
 
+	err = resolveDependencyVersions(modules)
+	if err != nil {
+		return fmt.Errorf("resolve dependency versions: %w", err)
+	}
+
 	var apiMenu []MenuItem
 
 	for _, module := range modules {
@@ -191,8 +298,13 @@ func Generate(
 		for api := range module.APIs {
 			conf := apiConf[api]
 
+			title := conf.Title
+			if module.LatestVersion.IsPrerelease {
+				title += " (pre-release)"
+			}
+
 			modItem.Children = append(modItem.Children, MenuItem{
-				Title: conf.Title,
+				Title: title,
 				HRef: fmt.Sprintf("/apis/%s/%s",
 					api, module.LatestVersion.Tag),
 			})
@@ -209,117 +321,88 @@ func Generate(
 		return strings.Compare(a.Title, b.Title)
 	})
 
+	if hasIncludes(modules) {
+		apiMenu = append(apiMenu, MenuItem{
+			Title: "Compatibility",
+			HRef:  "/compatibility",
+		})
+	}
+
 	// Load and resolve schemas if configured.
-	var schemaDoc *SchemaDoc
-	var schemaTag string
+	var (
+		schemaDoc      *SchemaDoc
+		schemaVersions []*SchemaVersion
+		schemaEntries  []FeedEntry
+	)
+
+	if conf.Schemas != nil && conf.Schemas.Playground {
+		err := checkPlayground()
+		if err != nil {
+			slog.Warn("skipping the schema playground", "err", err)
+
+			schemas := *conf.Schemas
+			schemas.Playground = false
+			conf.Schemas = &schemas
+		}
+	}
 
 	if conf.Schemas != nil {
 		uiPrintln("Cloning %s", conf.Schemas.Repo)
 
-		var schemaCommit *object.Commit
-
-		_, schemaCommit, schemaTag, err = cloneSchemaRepo(*conf.Schemas, schemaPrerelease)
+		schemaRepo, versions, latest, err := cloneSchemaRepo(*conf.Schemas)
 		if err != nil {
 			return fmt.Errorf("clone schema repo: %w", err)
 		}
 
-		uiPrintln("Using schema version %s", schemaTag)
-
-		constraintSets, err := loadConstraintSets(schemaCommit, *conf.Schemas)
-		if err != nil {
-			return fmt.Errorf("load constraint sets: %w", err)
-		}
+		uiPrintln("Using schema version %s", latest.Tag)
 
-		schemaDoc, err = resolveSchemas(constraintSets, *conf.Schemas)
+		schemaVersions, err = loadSchemaVersions(*conf.Schemas, versions, latest)
 		if err != nil {
-			return fmt.Errorf("resolve schemas: %w", err)
-		}
-
-		// Add schemas menu section.
-		schemaMenuItem := MenuItem{
-			Title: "Schemas",
+			return err
 		}
 
-		schemaMenuItem.Children = append(schemaMenuItem.Children, MenuItem{
-			Title: "Overview",
-			HRef:  "/schemas",
-		})
+		documented := make([]*ModuleVersion, len(schemaVersions))
 
-		docTypesItem := MenuItem{
-			Title: "Document Types",
-		}
-
-		for _, d := range schemaDoc.Documents {
-			name := d.Name
-			if name == "" {
-				name = d.Type
+		for i, sv := range schemaVersions {
+			if sv.IsLatest {
+				schemaDoc = sv.Doc
 			}
 
-			docTypesItem.Children = append(docTypesItem.Children, MenuItem{
-				Title: name,
-				HRef:  fmt.Sprintf("/schemas/documents/%s", d.Type),
-			})
+			documented[i] = sv.version
 		}
 
-		schemaMenuItem.Children = append(schemaMenuItem.Children, docTypesItem)
-
-		for _, kindInfo := range []struct {
-			kind  string
-			title string
-		}{
-			{"meta", "Meta Blocks"},
-			{"link", "Link Blocks"},
-			{"content", "Content Blocks"},
-		} {
-			kindItem := MenuItem{
-				Title: kindInfo.title,
-			}
-
-			for _, b := range schemaDoc.Blocks {
-				if b.Kind != kindInfo.kind {
-					continue
-				}
-
-				name := b.Block.Name
-				if name == "" {
-					name = b.ID
-				}
-
-				kindItem.Children = append(kindItem.Children, MenuItem{
-					Title: name,
-					HRef:  fmt.Sprintf("/schemas/blocks/%s/%s", b.Kind, SchemaSlug(b.ID)),
-				})
-			}
-
-			if len(kindItem.Children) > 0 {
-				schemaMenuItem.Children = append(schemaMenuItem.Children, kindItem)
-			}
+		changelog, err := getSchemaChangelog(
+			schemaRepo, *conf.Schemas, documented)
+		if err != nil {
+			return fmt.Errorf("get schema changelog: %w", err)
 		}
 
-		if len(schemaDoc.Policies) > 0 {
-			policiesItem := MenuItem{
-				Title: "HTML Policies",
-			}
+		for _, sv := range schemaVersions {
+			sv.Changelog = changelog.Until(sv.Tag)
+		}
 
-			for _, p := range schemaDoc.Policies {
-				policiesItem.Children = append(policiesItem.Children, MenuItem{
-					Title: p.Name,
-					HRef:  fmt.Sprintf("/schemas/policies/%s", p.Name),
-				})
-			}
+		schemaEntries = schemaFeedEntries(conf.Schemas.Title, documented)
 
-			schemaMenuItem.Children = append(schemaMenuItem.Children, policiesItem)
+		err = feeds.Write(SchemaFeedPath, conf.Schemas.Title,
+			"/schemas", schemaEntries)
+		if err != nil {
+			return fmt.Errorf("write schema feed: %w", err)
 		}
 
-		apiMenu = append(apiMenu, schemaMenuItem)
+		apiMenu = append(apiMenu, schemaMenu(schemaDoc, "/schemas",
+			conf.Schemas.Playground))
 	}
 
-	// Prepend the home item.
+	// Prepend the home and "what's new" items.
 	apiMenu = append([]MenuItem{
 		{
 			Title: "Home",
 			HRef:  "/",
 		},
+		{
+			Title: "What's new",
+			HRef:  "/whats-new",
+		},
 	}, apiMenu...)
 
 	jobs := make(chan collectJob)
@@ -359,21 +442,12 @@ func Generate(
 		var apiCards []APICard
 		for _, module := range modules {
 			version := module.LatestVersion
-			docCommit := version.Commit
-
-			// Use the latest docs from HEAD for the latest version
-			head, err := module.Repo.Head()
-			if err != nil {
-				return fmt.Errorf("get repo head: %w", err)
-			}
 
-			c, err := module.Repo.CommitObject(head.Hash())
+			docCommit, err := versionDocCommit(module, version)
 			if err != nil {
-				return fmt.Errorf("get repo head commit: %w", err)
+				return err
 			}
 
-			docCommit = c
-
 			apis, err := collectAPIData(modules, module, version, docCommit)
 			if err != nil {
 				return fmt.Errorf("collect API data for %s@%s: %w",
@@ -445,7 +519,14 @@ func Generate(
 		defer close(jobs)
 
 		for _, module := range modules {
-			for _, version := range module.Versions {
+			versions := module.Versions
+
+			if module.Unreleased != nil {
+				versions = slices.Concat(versions,
+					[]*ModuleVersion{module.Unreleased})
+			}
+
+			for _, version := range versions {
 				job := collectJob{
 					Module:  module,
 					Version: version,
@@ -486,26 +567,95 @@ func Generate(
 			return fmt.Errorf("clone templates: %w", err)
 		}
 
+		entries := schemaEntries
+
 		for _, module := range modules {
 			for api := range module.APIs {
-				err := renderAPILandingPages(modTemplate, outDir, basePath, apiMenu, module, api)
+				apiEntries, err := renderAPILandingPages(
+					modTemplate, outDir, basePath, apiMenu,
+					feeds, module, api)
 				if err != nil {
 					return fmt.Errorf("render %s landing page: %w",
 						api, err)
 				}
+
+				entries = append(entries, apiEntries...)
 			}
 		}
 
+		sortFeedEntries(entries)
+
+		err = feeds.Write(WhatsNewFeedPath, "What's new", "/whats-new", entries)
+		if err != nil {
+			return fmt.Errorf("write what's new feed: %w", err)
+		}
+
+		whatsNewPage := Page{
+			Title: "What's new",
+			Menu:  markActive(apiMenu, "/whats-new"),
+			Contents: WhatsNewPage{
+				Entries: entries,
+			},
+			Breadcrumb: []MenuItem{
+				{
+					Title: "Home",
+					HRef:  "/",
+				},
+				{
+					Title: "What's new",
+				},
+			},
+		}
+
+		err = renderPage(
+			filepath.Join(outDir, "whats-new"),
+			modTemplate, "whats_new.html", whatsNewPage)
+		if err != nil {
+			return fmt.Errorf("render what's new page: %w", err)
+		}
+
 		return nil
 	})
 
-	// Render schema pages.
-	if schemaDoc != nil {
+	if hasIncludes(modules) {
 		grp.Go(func() error {
-			return renderSchemaPages(outDir, schemaDoc, tpl, apiMenu, schemaTag)
+			compatTemplate, err := tpl.Clone()
+			if err != nil {
+				return fmt.Errorf("clone templates: %w", err)
+			}
+
+			return renderCompatibilityPage(
+				compatTemplate, outDir, apiMenu, modules)
 		})
 	}
 
+	if conf.Schemas != nil && conf.Schemas.Playground {
+		grp.Go(func() error {
+			err := buildPlayground(outDir)
+			if err != nil {
+				return fmt.Errorf("build schema playground: %w", err)
+			}
+
+			return nil
+		})
+	}
+
+	// Render schema pages for each version, and for the latest version
+	// under "/schemas".
+	for _, v := range schemaVersions {
+		grp.Go(func() error {
+			return renderSchemaPages(outDir, tpl, apiMenu,
+				schemaVersions, v, "/schemas/"+v.Tag)
+		})
+
+		if v.IsLatest {
+			grp.Go(func() error {
+				return renderSchemaPages(outDir, tpl, apiMenu,
+					schemaVersions, v, "/schemas")
+			})
+		}
+	}
+
 	err = grp.Wait()
 	if err != nil {
 		return fmt.Errorf("render documentation: %w", err)
@@ -713,24 +863,9 @@ func renderModuleVersionPages(
 	module := job.Module
 	version := job.Version
 
-	docCommit := version.Commit
-
-	// Use the latest docs (from HEAD) for the latest version. We don't want
-	// to have to tag new releases to improve documentation. Creates a bit
-	// of a discontinuity as a version will start showing older docs as soon
-	// as its replacement is tagged.
-	if version.Tag == module.LatestVersion.Tag {
-		head, err := module.Repo.Head()
-		if err != nil {
-			return fmt.Errorf("get repo head: %w", err)
-		}
-
-		c, err := module.Repo.CommitObject(head.Hash())
-		if err != nil {
-			return fmt.Errorf("get repo head commit: %w", err)
-		}
-
-		docCommit = c
+	docCommit, err := versionDocCommit(module, version)
+	if err != nil {
+		return err
 	}
 
 	apis, err := collectAPIData(modules, module, version, docCommit)
@@ -739,6 +874,11 @@ func renderModuleVersionPages(
 			module.Name, version.Tag, err)
 	}
 
+	docTree, err := module.Tree(docCommit)
+	if err != nil {
+		return fmt.Errorf("get documentation tree: %w", err)
+	}
+
 	localTpl, err := tpl.Clone()
 	if err != nil {
 		return fmt.Errorf("create local templates: %w", err)
@@ -768,7 +908,7 @@ func renderModuleVersionPages(
 		apiTpl.Funcs(localFuncs)
 
 		readme, err := renderMarkdownGitFileIfExists(
-			docCommit,
+			docTree,
 			fmt.Sprintf("%s/README.md", api),
 			markdownOptions{
 				HeadingShift: 3,
@@ -778,13 +918,23 @@ func renderModuleVersionPages(
 		}
 
 		d := API{
-			Name:          api,
-			Title:         conf.Title,
-			Version:       version.Tag,
-			Module:        module.Name,
-			LatestVersion: module.LatestVersion.Tag,
-			Data:          data,
-			Readme:        readme,
+			Name:               api,
+			Title:              conf.Title,
+			Version:            version.Tag,
+			Module:             module.Name,
+			LatestVersion:      module.LatestVersion.Tag,
+			Data:               data,
+			Readme:             readme,
+			Branch:             version.Branch,
+			IsPrerelease:       version.IsPrerelease,
+			LatestIsPrerelease: module.LatestVersion.IsPrerelease,
+			HasUnreleased:      module.Unreleased != nil,
+			DocsNewerThanTag:   docCommit.Hash != version.Commit.Hash,
+			BuiltAgainst:       dependencyRefs(modules, version),
+			Lifecycle:          lifecycleNotice(version, api),
+			SourceURL: module.Links.FileURL(
+				cmp.Or(version.TagName, version.Branch, version.Tag),
+				api),
 		}
 
 		apiDir := filepath.Join("apis", api)
@@ -857,6 +1007,7 @@ func renderModuleVersionPages(
 						Response:    method.Response,
 						Doc:         method.Doc,
 						Readme:      method.Readme,
+						Lifecycle:   lifecycleNotice(version, api),
 					}
 
 					methodDir := filepath.Join(versionOutDir, "methods", service.Name, method.Name)
@@ -866,8 +1017,8 @@ func renderModuleVersionPages(
 					}
 
 					methodPageData := Page{
-						Title: method.Name,
-						Menu:  markActive(apiMenu, "/"+apiDir),
+						Title:    method.Name,
+						Menu:     markActive(apiMenu, "/"+apiDir),
 						Contents: methodPage,
 						Breadcrumb: []MenuItem{
 							{
@@ -908,6 +1059,31 @@ func renderModuleVersionPages(
 	return nil
 }
 
+// versionDocCommit returns the commit that documentation should be read from
+// for a module version.
+//
+// Modules with HeadDocs enabled use the latest docs (from HEAD) for the latest
+// version. We don't want to have to tag new releases to improve documentation.
+// Creates a bit of a discontinuity as a version will start showing older docs
+// as soon as its replacement is tagged.
+func versionDocCommit(module *Module, version *ModuleVersion) (*object.Commit, error) {
+	if !module.HeadDocs || version.Tag != module.LatestVersion.Tag {
+		return version.Commit, nil
+	}
+
+	head, err := module.Repo.Head()
+	if err != nil {
+		return nil, fmt.Errorf("get repo head: %w", err)
+	}
+
+	c, err := module.Repo.CommitObject(head.Hash())
+	if err != nil {
+		return nil, fmt.Errorf("get repo head commit: %w", err)
+	}
+
+	return c, nil
+}
+
 func markActive(menu []MenuItem, path string) []MenuItem {
 	if len(menu) == 0 {
 		return menu
@@ -931,6 +1107,7 @@ func markActive(menu []MenuItem, path string) []MenuItem {
 type APILandingPage struct {
 	Name             string
 	Version          string
+	IsPrerelease     bool
 	RedirectLocation string
 }
 
@@ -941,15 +1118,18 @@ type ChangelogPage struct {
 	Versions []*ModuleVersion
 }
 
+// renderAPILandingPages renders the changelog, feed and redirect page of an
+// API and returns its feed entries.
 func renderAPILandingPages(
 	tpl *template.Template,
 	outDir string, basePath string,
 	menu []MenuItem,
+	feeds *feedWriter,
 	module *Module, api string,
-) error {
+) ([]FeedEntry, error) {
 	conf, ok := module.APIs[api]
 	if !ok {
-		return errors.New("missing API configuration")
+		return nil, errors.New("missing API configuration")
 	}
 
 	apiDir := filepath.Join("apis", api)
@@ -960,12 +1140,23 @@ func renderAPILandingPages(
 
 	log, err := getChangelog(module, api)
 	if err != nil {
-		return fmt.Errorf("get module changelog: %w", err)
+		return nil, fmt.Errorf("get module changelog: %w", err)
+	}
+
+	entries := apiFeedEntries(conf.Title, api, log)
+
+	err = feeds.Write(APIFeedPath(api), conf.Title,
+		"/"+apiDir+"/changelog", entries)
+	if err != nil {
+		return nil, fmt.Errorf("write feed: %w", err)
 	}
 
 	changelogPage := Page{
 		Title: conf.Title,
 		Menu:  menu,
+		Feeds: []FeedLink{
+			{Title: conf.Title, HRef: APIFeedPath(api)},
+		},
 		Contents: ChangelogPage{
 			Module:   module,
 			Name:     api,
@@ -991,7 +1182,7 @@ func renderAPILandingPages(
 		filepath.Join(apiOutDir, "changelog"),
 		tpl, "api_changelog.html", changelogPage)
 	if err != nil {
-		return fmt.Errorf(
+		return nil, fmt.Errorf(
 			"render api page for %s: %w",
 			api, err)
 	}
@@ -1011,6 +1202,7 @@ func renderAPILandingPages(
 		Contents: APILandingPage{
 			Name:             api,
 			Version:          module.LatestVersion.Tag,
+			IsPrerelease:     module.LatestVersion.IsPrerelease,
 			RedirectLocation: redirectURL,
 		},
 		Breadcrumb: []MenuItem{
@@ -1032,12 +1224,12 @@ func renderAPILandingPages(
 		apiOutDir,
 		tpl, "api_redirect.html", redirectPage)
 	if err != nil {
-		return fmt.Errorf(
+		return nil, fmt.Errorf(
 			"render redirect page for %s: %w",
 			api, err)
 	}
 
-	return nil
+	return entries, nil
 }
 
 func apiMessageHRef(data APIData, basePath string) func(ref MessageRef) string {
@@ -1207,51 +1399,27 @@ func collectAPIData(
 	module *Module, version *ModuleVersion,
 	docCommit *object.Commit,
 ) (map[string]APIData, error) {
-	dependencies, err := readDepVersions(version.Commit, module.Include)
+	tree, err := module.Tree(version.Commit)
 	if err != nil {
-		return nil, fmt.Errorf("resolve dependency versions: %w", err)
+		return nil, fmt.Errorf("get version tree: %w", err)
 	}
 
-	files := map[string]ProtoHandle{}
-
-	for _, dep := range dependencies {
-		depMod, ok := modules[dep.Module]
-		if !ok {
-			return nil, fmt.Errorf("unknown module %q", dep.Module)
-		}
-
-		_, ok = depMod.APIs[dep.API]
-		if !ok {
-			return nil, fmt.Errorf("module %q doesn't expose the API %q",
-				dep.Module, dep.API)
-		}
-
-		depVersion, ok := depMod.VersionLookup[dep.Version]
-		if !ok {
-			return nil, fmt.Errorf("no tagged version %q of %q",
-				dep.Version, dep.Module)
-		}
+	docTree, err := module.Tree(docCommit)
+	if err != nil {
+		return nil, fmt.Errorf("get documentation tree: %w", err)
+	}
 
-		protos, err := parseProtoFiles(depVersion, dep.API)
-		if err != nil {
-			return nil, fmt.Errorf("parse files in dependency %q in %q: %w",
-				dep.API, dep.Module, err)
-		}
+	files := map[string]ProtoHandle{}
 
-		for _, pd := range protos {
-			files[pd.File] = ProtoHandle{
-				API:     dep.API,
-				Module:  dep.Module,
-				Version: dep.Version,
-				Proto:   pd,
-			}
-		}
+	err = collectDependencyFiles(modules, module, version.Commit, files)
+	if err != nil {
+		return nil, err
 	}
 
 	apis := map[string][]ProtoDeclarations{}
 
 	for apiName := range module.APIs {
-		protos, err := parseProtoFiles(version, apiName)
+		protos, err := parseProtoFiles(tree, apiName)
 		if err != nil {
 			return nil, fmt.Errorf("parse proto files: %w", err)
 		}
@@ -1289,7 +1457,7 @@ func collectAPIData(
 					m := &s.Methods[j]
 
 					readme, err := renderMarkdownGitFileIfExists(
-						docCommit,
+						docTree,
 						fmt.Sprintf("%s/docs/%s.%s.md",
 							apiName,
 							s.Name,
@@ -1308,7 +1476,7 @@ func collectAPIData(
 
 			for i := range p.Messages {
 				readme, err := renderMarkdownGitFileIfExists(
-					docCommit,
+					docTree,
 					fmt.Sprintf("%s/docs/%s.md", apiName, p.Messages[i].Name),
 					markdownOptions{
 						HeadingShift: 3,
@@ -1322,7 +1490,7 @@ func collectAPIData(
 
 			for i := range p.Enums {
 				readme, err := renderMarkdownGitFileIfExists(
-					docCommit,
+					docTree,
 					fmt.Sprintf("%s/docs/%s.md", apiName, p.Enums[i].Name),
 					markdownOptions{
 						HeadingShift: 3,
@@ -1341,9 +1509,10 @@ func collectAPIData(
 				}
 
 				data.Dependencies[h.Proto.Package] = API{
-					Name:    h.API,
-					Version: h.Version,
-					Module:  h.Module,
+					Name:           h.API,
+					Version:        h.Version,
+					Module:         h.Module,
+					UntaggedCommit: h.Commit,
 					Data: APIData{
 						Declarations: []ProtoDeclarations{
 							h.Proto,
@@ -1359,24 +1528,47 @@ func collectAPIData(
 	return apiData, nil
 }
 
+// documentedVersion returns the version itself if it's documented, otherwise
+// the oldest documented version that is newer than it, falling back to the
+// latest version.
+func documentedVersion(module *Module, version *ModuleVersion) *ModuleVersion {
+	var closest *ModuleVersion
+
+	// Versions are sorted in descending order.
+	for _, v := range module.Versions {
+		if v.Tag == version.Tag {
+			return v
+		}
+
+		if v.Version.GreaterThan(version.Version) {
+			closest = v
+		}
+	}
+
+	if closest != nil {
+		return closest
+	}
+
+	return module.LatestVersion
+}
+
 type depSpec struct {
 	API     string
 	Module  string
 	Version string
+	// Replace is set when a replace directive in go.mod points the
+	// dependency elsewhere.
+	Replace *module.Version
 }
 
 func readDepVersions(
-	commit *object.Commit, include map[string]IncludeConfig,
+	tree *object.Tree, include map[string]IncludeConfig,
+	modules map[string]*Module,
 ) (map[string]depSpec, error) {
 	if len(include) == 0 {
 		return map[string]depSpec{}, nil
 	}
 
-	tree, err := commit.Tree()
-	if err != nil {
-		return nil, fmt.Errorf("get tag tree: %w", err)
-	}
-
 	modF, err := tree.File("go.mod")
 	if err != nil {
 		return nil, fmt.Errorf("get go.mod: %w", err)
@@ -1384,6 +1576,7 @@ func readDepVersions(
 
 	rc, err := modF.Reader()
 	if err != nil {
+		return nil, fmt.Errorf("open go.mod: %w", err)
 	}
 
 	defer func() {
@@ -1398,6 +1591,17 @@ func readDepVersions(
 		return nil, fmt.Errorf("read go.mod: %w", err)
 	}
 
+	return parseDepVersions(modData, include, modules)
+}
+
+// parseDepVersions lists the requirements of a go.mod file that are included
+// APIs. Includes from a module match requirements on its other major versions
+// as well, as long as they are configured as modules so that they can be
+// resolved.
+func parseDepVersions(
+	modData []byte, include map[string]IncludeConfig,
+	modules map[string]*Module,
+) (map[string]depSpec, error) {
 	goMod, err := modfile.Parse("go.mod", modData, nil)
 	if err != nil {
 		return nil, fmt.Errorf("parse go.mod: %w", err)
@@ -1413,15 +1617,40 @@ func readDepVersions(
 
 	for _, req := range goMod.Require {
 		api, included := reverseInc[req.Mod.Path]
+		if !included && modules[req.Mod.Path] != nil {
+			// Includes from a module apply to all its configured
+			// major versions, so that "example.com/api" also
+			// matches a requirement on "example.com/api/v2".
+			prefix, _, ok := module.SplitPathVersion(req.Mod.Path)
+			if ok {
+				api, included = reverseInc[prefix]
+			}
+		}
+
 		if !included {
 			continue
 		}
 
-		deps[req.Mod.Path] = depSpec{
+		dep := depSpec{
 			API:     api,
 			Module:  req.Mod.Path,
 			Version: req.Mod.Version,
 		}
+
+		// A replace for a specific version takes precedence over a
+		// replace for all versions of the module.
+		for _, rep := range goMod.Replace {
+			if rep.Old.Path != req.Mod.Path {
+				continue
+			}
+
+			if rep.Old.Version == req.Mod.Version ||
+				(rep.Old.Version == "" && dep.Replace == nil) {
+				dep.Replace = &rep.New
+			}
+		}
+
+		deps[req.Mod.Path] = dep
 	}
 
 	return deps, nil
@@ -0,0 +1,96 @@
+package elephantdocs
+
+import (
+	"maps"
+	"slices"
+	"testing"
+)
+
+func TestParseDepVersionsMajorVersions(t *testing.T) {
+	goMod := []byte(`module example.com/app
+
+go 1.24
+
+require (
+	example.com/api/v2 v2.1.0
+	example.com/other/v3 v3.0.0
+	example.com/unrelated v1.0.0
+)
+
+replace example.com/api/v2 v2.1.0 => ../api
+`)
+
+	include := map[string]IncludeConfig{
+		"api":   {From: "example.com/api"},
+		"other": {From: "example.com/other"},
+	}
+
+	modules := map[string]*Module{
+		"example.com/api":    {Name: "example.com/api"},
+		"example.com/api/v2": {Name: "example.com/api/v2"},
+		"example.com/other":  {Name: "example.com/other"},
+	}
+
+	deps, err := parseDepVersions(goMod, include, modules)
+	if err != nil {
+		t.Fatalf("parse dependencies: %v", err)
+	}
+
+	// The requirement on "example.com/other/v3" can't be resolved, as
+	// there's no module configured for that major version.
+	want := []string{"example.com/api/v2"}
+
+	got := slices.Sorted(maps.Keys(deps))
+	if !slices.Equal(got, want) {
+		t.Fatalf("got dependencies %q, want %q", got, want)
+	}
+
+	dep := deps["example.com/api/v2"]
+
+	if dep.API != "api" {
+		t.Errorf("got API %q, want %q", dep.API, "api")
+	}
+
+	if dep.Version != "v2.1.0" {
+		t.Errorf("got version %q, want %q", dep.Version, "v2.1.0")
+	}
+
+	if _, ok := modules[dep.Module]; !ok {
+		t.Errorf("dependency module %q isn't a configured module", dep.Module)
+	}
+
+	if dep.Replace == nil || dep.Replace.Path != "../api" {
+		t.Errorf("got replace %v, want ../api", dep.Replace)
+	}
+}
+
+func TestParseDepVersionsExactMatch(t *testing.T) {
+	goMod := []byte(`module example.com/app
+
+go 1.24
+
+require example.com/api v1.4.0
+`)
+
+	include := map[string]IncludeConfig{
+		"api": {From: "example.com/api"},
+	}
+
+	modules := map[string]*Module{
+		"example.com/api": {Name: "example.com/api"},
+	}
+
+	deps, err := parseDepVersions(goMod, include, modules)
+	if err != nil {
+		t.Fatalf("parse dependencies: %v", err)
+	}
+
+	dep, ok := deps["example.com/api"]
+	if !ok {
+		t.Fatal("the requirement on example.com/api wasn't matched")
+	}
+
+	if dep.API != "api" || dep.Version != "v1.4.0" {
+		t.Errorf("got %s@%s, want api@v1.4.0", dep.API, dep.Version)
+	}
+}
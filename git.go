@@ -6,6 +6,7 @@ import (
 	"os"
 	"slices"
 	"strings"
+	"time"
 
 	"github.com/Masterminds/semver/v3"
 	"github.com/go-git/go-git/v6"
@@ -13,22 +14,33 @@ import (
 	"github.com/go-git/go-git/v6/plumbing/object"
 	"github.com/go-git/go-git/v6/storage/memory"
 	"github.com/ttab/elephant-docs/internal"
+	"golang.org/x/mod/module"
 )
 
-// cloneAndFindLatestTag clones a repo into memory and finds the latest
-// non-prerelease semver tag. Returns the repo, latest stable commit, and tag name.
-func cloneAndFindLatestTag(cloneURL string, allowPrerelease bool) (*git.Repository, *object.Commit, string, error) {
+// cloneRepo clones a repo into memory using the configured authentication.
+func cloneRepo(cloneURL string, authConf *AuthConfig) (*git.Repository, error) {
+	auth, err := cloneAuth(cloneURL, authConf)
+	if err != nil {
+		return nil, fmt.Errorf("configure authentication: %w", err)
+	}
+
 	repo, err := git.Clone(memory.NewStorage(), nil, &git.CloneOptions{
 		URL:      cloneURL,
+		Auth:     auth,
 		Progress: os.Stderr,
 	})
 	if err != nil {
-		return nil, nil, "", fmt.Errorf("git clone: %w", err)
+		return nil, fmt.Errorf("git clone: %w", err)
 	}
 
+	return repo, nil
+}
+
+// listVersionTags lists the semver tags of a repository in descending order.
+func listVersionTags(repo *git.Repository) ([]*ModuleVersion, error) {
 	tagsRefs, err := repo.Tags()
 	if err != nil {
-		return nil, nil, "", fmt.Errorf("list tags: %w", err)
+		return nil, fmt.Errorf("list tags: %w", err)
 	}
 
 	var versions []*ModuleVersion
@@ -49,57 +61,68 @@ func cloneAndFindLatestTag(cloneURL string, allowPrerelease bool) (*git.Reposito
 			return err
 		}
 
+		notes, tagged, err := getTagInfo(repo, tagRef)
+		if err != nil {
+			return err
+		}
+
 		versions = append(versions, &ModuleVersion{
 			Tag:          name,
+			TagName:      name,
 			Commit:       commit,
 			Version:      version,
 			IsPrerelease: version.Prerelease() != "",
+			ReleaseNotes: notes,
+			Tagged:       tagged,
 		})
 
 		return nil
 	})
 	if err != nil {
-		return nil, nil, "", fmt.Errorf("collect version tags: %w", err)
+		return nil, fmt.Errorf("collect version tags: %w", err)
 	}
 
-	slices.SortFunc(versions, func(a, b *ModuleVersion) int {
-		return a.Version.Compare(b.Version)
-	})
-
-	slices.Reverse(versions)
+	sortVersionsDesc(versions)
 
-	for _, v := range versions {
-		if !allowPrerelease && v.Version.Prerelease() != "" {
-			continue
-		}
+	return versions, nil
+}
 
-		return repo, v.Commit, v.Tag, nil
+func newModule(mod ModuleConfig) (*Module, error) {
+	switch mod.Source {
+	case "", SourceGit:
+	case SourceProxy:
+		return newProxyModule(mod)
+	default:
+		return nil, fmt.Errorf("unknown module source %q", mod.Source)
 	}
 
-	return nil, nil, "", errors.New("no version tags found")
-}
+	layout, err := newRepoLayout(mod)
+	if err != nil {
+		return nil, err
+	}
 
-func newModule(mod ModuleConfig) (*Module, error) {
-	cloneURL := mod.Clone
-	if cloneURL == "" {
-		cloneURL = fmt.Sprintf("https://%s", mod.Name)
+	links, err := newSourceLinks(mod, layout.CloneURL)
+	if err != nil {
+		return nil, fmt.Errorf("configure source links: %w", err)
 	}
 
-	repo, err := git.Clone(memory.NewStorage(), nil, &git.CloneOptions{
-		URL:      cloneURL,
-		Progress: os.Stderr,
-	})
+	repo, err := cloneRepo(layout.CloneURL, mod.Auth)
 	if err != nil {
-		return nil, fmt.Errorf("git clone: %w", err)
+		return nil, err
 	}
 
 	module := Module{
 		Title:         mod.Title,
 		Name:          mod.Name,
+		Source:        SourceGit,
+		Dir:           mod.Dir,
 		Repo:          repo,
 		VersionLookup: make(map[string]*ModuleVersion),
 		APIs:          mod.APIs,
 		Include:       mod.Include,
+		HeadDocs:      mod.HeadDocs,
+		Changelog:     mod.Changelog,
+		Links:         links,
 	}
 
 	tagsRefs, err := repo.Tags()
@@ -109,11 +132,13 @@ func newModule(mod ModuleConfig) (*Module, error) {
 
 	err = tagsRefs.ForEach(func(tagRef *plumbing.Reference) error {
 		name := tagRef.Name().Short()
-		if !strings.HasPrefix(name, "v") {
+
+		tag, ok := layout.VersionFromTag(name)
+		if !ok {
 			return nil
 		}
 
-		version, err := semver.NewVersion(name)
+		version, err := semver.NewVersion(tag)
 		if err != nil {
 			return nil
 		}
@@ -123,11 +148,19 @@ func newModule(mod ModuleConfig) (*Module, error) {
 			return err
 		}
 
+		notes, tagged, err := getTagInfo(repo, tagRef)
+		if err != nil {
+			return err
+		}
+
 		mv := ModuleVersion{
-			Tag:          name,
+			Tag:          tag,
+			TagName:      name,
 			Commit:       commit,
 			Version:      version,
 			IsPrerelease: version.Prerelease() != "",
+			ReleaseNotes: notes,
+			Tagged:       tagged,
 		}
 
 		module.Versions = append(module.Versions, &mv)
@@ -139,50 +172,326 @@ func newModule(mod ModuleConfig) (*Module, error) {
 		return nil, fmt.Errorf("collect version tags: %w", err)
 	}
 
-	slices.SortFunc(module.Versions, func(a, b *ModuleVersion) int {
+	head, err := repo.Head()
+	if err != nil {
+		return nil, fmt.Errorf("get repository head: %w", err)
+	}
+
+	headCommit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		return nil, fmt.Errorf("get head commit: %w", err)
+	}
+
+	if mod.Unreleased != nil {
+		unreleased, err := unreleasedVersion(repo, *mod.Unreleased)
+		if err != nil {
+			return nil, fmt.Errorf("resolve unreleased version: %w", err)
+		}
+
+		module.Unreleased = unreleased
+	}
+
+	err = selectVersions(&module, mod, headCommit)
+	if err != nil {
+		return nil, err
+	}
+
+	return &module, nil
+}
+
+// repoLayout describes where a module lives in its repository.
+type repoLayout struct {
+	CloneURL string
+	// TagPrefix is the prefix of the version tags of the module, like
+	// "rpc/" for tags like "rpc/v1.2.3".
+	TagPrefix string
+	// PathMajor is the major version suffix of the module path, like
+	// "/v2", or empty for v0 and v1 modules.
+	PathMajor string
+}
+
+// newRepoLayout works out the clone URL and tag naming of a module from its
+// configuration, following the Go conventions for modules in subdirectories
+// and major version suffixes.
+func newRepoLayout(mod ModuleConfig) (repoLayout, error) {
+	repoPath, pathMajor, ok := module.SplitPathVersion(mod.Name)
+	if !ok {
+		return repoLayout{}, fmt.Errorf("invalid module path %q", mod.Name)
+	}
+
+	dir := strings.Trim(mod.Dir, "/")
+
+	// A module can live in a major version subdirectory, like "v2/",
+	// that isn't part of the tag names.
+	majorDir := strings.TrimPrefix(pathMajor, "/")
+
+	tagPrefix := mod.TagPrefix
+
+	if tagPrefix == "" && dir != "" && dir != majorDir {
+		tagPrefix = strings.TrimSuffix(dir, "/"+majorDir) + "/"
+	}
+
+	cloneURL := mod.Clone
+	if cloneURL == "" {
+		modDir := strings.TrimSuffix(dir, "/"+majorDir)
+		if modDir == majorDir {
+			modDir = ""
+		}
+
+		if modDir != "" {
+			repoPath = strings.TrimSuffix(repoPath, "/"+modDir)
+		}
+
+		cloneURL = fmt.Sprintf("https://%s", repoPath)
+	}
+
+	return repoLayout{
+		CloneURL:  cloneURL,
+		TagPrefix: tagPrefix,
+		PathMajor: pathMajor,
+	}, nil
+}
+
+// VersionFromTag returns the version for a tag name, and false if the tag
+// isn't a version tag for the module.
+func (l repoLayout) VersionFromTag(name string) (string, bool) {
+	v, ok := strings.CutPrefix(name, l.TagPrefix)
+	if !ok || !strings.HasPrefix(v, "v") {
+		return "", false
+	}
+
+	// Only accept versions that are valid for the major version of the
+	// module path, "+incompatible" versions are allowed for modules
+	// without a major version suffix.
+	if module.CheckPathMajor(v, l.PathMajor) != nil {
+		return "", false
+	}
+
+	return v, true
+}
+
+// unreleasedVersion creates a version for the head of the configured branch,
+// or for HEAD if no branch has been configured.
+func unreleasedVersion(
+	repo *git.Repository, conf UnreleasedConfig,
+) (*ModuleVersion, error) {
+	var (
+		ref *plumbing.Reference
+		err error
+	)
+
+	if conf.Branch == "" {
+		ref, err = repo.Head()
+	} else {
+		ref, err = repo.Reference(
+			plumbing.NewRemoteReferenceName("origin", conf.Branch), true)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("get branch reference: %w", err)
+	}
+
+	commit, err := repo.CommitObject(ref.Hash())
+	if err != nil {
+		return nil, fmt.Errorf("get branch commit: %w", err)
+	}
+
+	branch := conf.Branch
+	if branch == "" {
+		branch = ref.Name().Short()
+	}
+
+	return &ModuleVersion{
+		Tag:          UnreleasedTag,
+		Commit:       commit,
+		IsUnreleased: true,
+		Branch:       branch,
+	}, nil
+}
+
+// selectVersions sorts the module versions in descending order, sets their
+// lifecycle status, applies the version policy, and selects the latest version
+// for the release channel. Versions that are excluded by the policy are kept in
+// the version lookup so that they still can be resolved as dependencies.
+//
+// The lifecycle file is read from the head commit, or from the newest version
+// if head is nil. Modules without any versions fall back to their unreleased
+// version, if one has been configured.
+func selectVersions(module *Module, conf ModuleConfig, head *object.Commit) error {
+	sortVersionsDesc(module.Versions)
+
+	if head == nil && len(module.Versions) > 0 {
+		head = module.Versions[0].Commit
+	}
+
+	rules, err := lifecycleRules(module, conf, head)
+	if err != nil {
+		return fmt.Errorf("load lifecycle rules: %w", err)
+	}
+
+	err = applyLifecycle(module.Versions, rules)
+	if err != nil {
+		return fmt.Errorf("apply lifecycle rules: %w", err)
+	}
+
+	versions, err := applyVersionPolicy(module.Versions, conf.Versions)
+	if err != nil {
+		return fmt.Errorf("apply version policy: %w", err)
+	}
+
+	module.Versions = versions
+
+	// A module that hasn't been tagged yet can still be documented from
+	// its unreleased branch.
+	if len(module.Versions) == 0 && module.Unreleased != nil {
+		module.LatestVersion = module.Unreleased
+
+		return nil
+	}
+
+	latest, err := latestVersion(module.Versions, conf.Channel)
+	if err != nil {
+		return err
+	}
+
+	module.LatestVersion = latest
+
+	err = setReplacements(module)
+	if err != nil {
+		return fmt.Errorf("resolve lifecycle replacements: %w", err)
+	}
+
+	return nil
+}
+
+func sortVersionsDesc(versions []*ModuleVersion) {
+	slices.SortFunc(versions, func(a, b *ModuleVersion) int {
 		return a.Version.Compare(b.Version)
 	})
 
-	slices.Reverse(module.Versions)
+	slices.Reverse(versions)
+}
+
+// latestVersion picks the latest version for the release channel from a list
+// of versions sorted in descending order. The stable channel falls back to the
+// newest pre-release if there are no stable versions. Yanked versions are
+// never picked.
+func latestVersion(versions []*ModuleVersion, channel string) (*ModuleVersion, error) {
+	switch channel {
+	case "", ChannelStable, ChannelPrerelease:
+	default:
+		return nil, fmt.Errorf("unknown release channel %q", channel)
+	}
+
+	if len(versions) == 0 {
+		return nil, errors.New("no version tags found")
+	}
+
+	versions = slices.DeleteFunc(slices.Clone(versions), func(v *ModuleVersion) bool {
+		return v.Lifecycle != nil && v.Lifecycle.Status == LifecycleYanked
+	})
 
-	for _, v := range module.Versions {
-		if v.Version.Prerelease() != "" {
+	if len(versions) == 0 {
+		return nil, errors.New("all versions have been yanked")
+	}
+
+	if channel == ChannelPrerelease {
+		return versions[0], nil
+	}
+
+	for _, v := range versions {
+		if v.IsPrerelease {
 			continue
 		}
 
-		module.LatestVersion = v
+		return v, nil
+	}
 
-		break
+	return versions[0], nil
+}
+
+// cloneSchemaRepo clones the schema repository and returns its version tags
+// in descending order, filtered by the version policy, together with the
+// latest version of the configured release channel.
+func cloneSchemaRepo(
+	conf SchemaGroupConfig,
+) (*git.Repository, []*ModuleVersion, *ModuleVersion, error) {
+	repo, versions, err := listSchemaVersions(conf)
+	if err != nil {
+		return nil, nil, nil, err
 	}
 
-	return &module, nil
+	versions, err = applyVersionPolicy(versions, conf.Versions)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("apply version policy: %w", err)
+	}
+
+	latest, err := latestVersion(versions, conf.Channel)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return repo, versions, latest, nil
 }
 
-// cloneSchemaRepo clones the schema repository and returns the commit for
-// the latest stable version tag.
-func cloneSchemaRepo(conf SchemaGroupConfig, allowPrerelease bool) (*git.Repository, *object.Commit, string, error) {
-	cloneURL := conf.Clone
-	if cloneURL == "" {
-		cloneURL = fmt.Sprintf("https://%s", conf.Repo)
+// schemaCloneURL returns the clone URL of the schema repository.
+func schemaCloneURL(conf SchemaGroupConfig) string {
+	if conf.Clone != "" {
+		return conf.Clone
 	}
 
-	return cloneAndFindLatestTag(cloneURL, allowPrerelease)
+	return fmt.Sprintf("https://%s", conf.Repo)
+}
+
+// listSchemaVersions clones the schema repository and lists all its version
+// tags in descending order.
+func listSchemaVersions(
+	conf SchemaGroupConfig,
+) (*git.Repository, []*ModuleVersion, error) {
+	repo, err := cloneRepo(schemaCloneURL(conf), conf.Auth)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	versions, err := listVersionTags(repo)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return repo, versions, nil
 }
 
 func getChangelog(module *Module, api string) ([]*ModuleVersion, error) {
-	if len(module.Versions) == 0 {
+	// A module that hasn't been tagged yet can still have unreleased
+	// changes.
+	if len(module.Versions) == 0 && module.Unreleased == nil {
 		return nil, nil
 	}
 
-	versions := make([]*ModuleVersion, 0, len(module.Versions))
+	// Modules from a module proxy have no commit history, so list the
+	// versions without any log entries.
+	hasHistory := module.Source != SourceProxy
+
+	candidates := module.Versions
+
+	// List unreleased changes first, unless the branch is at a tagged
+	// commit.
+	if module.Unreleased != nil &&
+		len(VersionsAtCommit(module.Unreleased.Commit.Hash, module.Versions)) == 0 {
+		candidates = append([]*ModuleVersion{module.Unreleased}, candidates...)
+	}
+
+	versions := make([]*ModuleVersion, 0, len(candidates))
 
 	// Semi-deep clone so that we don't pollute the shared Log slice.
-	for i := range module.Versions {
-		m := *module.Versions[i]
+	for i := range candidates {
+		m := *candidates[i]
 
-		tree, err := m.Commit.Tree()
-		if err != nil {
-			return nil, fmt.Errorf("get commit tree: %w", err)
+		tree, err := module.Tree(m.Commit)
+		if errors.Is(err, object.ErrDirectoryNotFound) {
+			continue
+		} else if err != nil {
+			return nil, fmt.Errorf("get module tree: %w", err)
 		}
 
 		_, err = tree.Tree(api)
@@ -201,33 +510,68 @@ func getChangelog(module *Module, api string) ([]*ModuleVersion, error) {
 		return nil, nil
 	}
 
-	log, err := module.Repo.Log(&git.LogOptions{
+	if !hasHistory {
+		return versions, nil
+	}
+
+	filter, err := newChangelogFilter(module.Changelog)
+	if err != nil {
+		return nil, fmt.Errorf("changelog configuration: %w", err)
+	}
+
+	err = collectVersionLogs(module.Repo, versions,
+		changelogPathFilter(module, api))
+	if err != nil {
+		return nil, err
+	}
+
+	summarizer := newProtoChangeSummarizer(module, api)
+
+	for _, v := range versions {
+		v.Groups = groupChangelog(v.Log, filter)
+
+		for _, g := range v.Groups {
+			for i := range g.Entries {
+				g.Entries[i].ProtoChanges = summarizer.Summarize(
+					g.Entries[i].Commit)
+			}
+		}
+	}
+
+	return versions, nil
+}
+
+// collectVersionLogs adds the commits that match the path filter to the log of
+// the versions that they were released in. Versions must be sorted in
+// descending order.
+func collectVersionLogs(
+	repo *git.Repository, versions []*ModuleVersion,
+	pathFilter internal.CommitFilter,
+) error {
+	log, err := repo.Log(&git.LogOptions{
 		From:  versions[0].Commit.Hash,
 		Order: git.LogOrderCommitterTime,
 	})
 	if err != nil {
-		return nil, fmt.Errorf("get git log: %w", err)
+		return fmt.Errorf("get git log: %w", err)
 	}
 
 	inScope := map[string]bool{}
 
-	filtered := internal.NewCommitPathIterFromIter(
-		func(c *object.Commit, names []string) bool {
-			var ok bool
-
-			for i := range names {
-				ok = strings.HasPrefix(names[i], api+"/")
-				if ok {
-					inScope[c.Hash.String()] = true
+	tagged := func(c *object.Commit, _ []string) bool {
+		return len(VersionsAtCommit(c.Hash, versions)) > 0
+	}
 
-					break
-				}
+	changed := internal.Record(
+		pathFilter,
+		func(c *object.Commit, matched bool) {
+			if matched {
+				inScope[c.Hash.String()] = true
 			}
+		})
 
-			tagCount := len(VersionsAtCommit(c.Hash, versions))
-
-			return ok || tagCount > 0
-		}, log)
+	filtered := internal.NewCommitPathIterFromIter(
+		internal.Or(changed, tagged), log)
 
 	var accumulators []*ModuleVersion
 
@@ -255,14 +599,73 @@ func getChangelog(module *Module, api string) ([]*ModuleVersion, error) {
 		return nil
 	})
 	if err != nil {
-		return nil, fmt.Errorf("read git log: %w", err)
+		return fmt.Errorf("read git log: %w", err)
 	}
 
-	return versions, nil
+	return nil
+}
+
+// changelogPathFilter returns a filter that matches the commits that changed
+// files in scope for the changelog of the API.
+func changelogPathFilter(module *Module, api string) internal.CommitFilter {
+	include := []string{api + "/"}
+
+	var exclude []string
+
+	if conf := module.APIs[api].Changelog; conf != nil {
+		if len(conf.Include) > 0 {
+			include = conf.Include
+		}
+
+		exclude = conf.Exclude
+	}
+
+	included := internal.GlobMatcher(modulePaths(module, include))
+	excluded := internal.GlobMatcher(modulePaths(module, exclude))
+
+	return internal.AnyPath(func(name string) bool {
+		return included(name) && !excluded(name)
+	})
+}
+
+// modulePaths makes glob patterns relative to the module directory relative
+// to the repository root.
+func modulePaths(module *Module, patterns []string) []string {
+	if module.Dir == "" {
+		return patterns
+	}
+
+	result := make([]string, len(patterns))
+
+	for i, p := range patterns {
+		result[i] = module.Dir + "/" + p
+	}
+
+	return result
 }
 
 func isPrerelease(v *ModuleVersion) bool {
-	return v.Version.Prerelease() != ""
+	return v.IsPrerelease
+}
+
+// getTagInfo returns the message and date of an annotated tag. Lightweight tags
+// have no message, and are dated by their commit.
+func getTagInfo(
+	repo *git.Repository, tagRef *plumbing.Reference,
+) (string, time.Time, error) {
+	t, err := repo.TagObject(tagRef.Hash())
+	if errors.Is(err, plumbing.ErrObjectNotFound) {
+		c, err := repo.CommitObject(tagRef.Hash())
+		if err != nil {
+			return "", time.Time{}, fmt.Errorf("get tag commit: %w", err)
+		}
+
+		return "", c.Committer.When, nil
+	} else if err != nil {
+		return "", time.Time{}, fmt.Errorf("get tag object: %w", err)
+	}
+
+	return strings.TrimSpace(t.Message), t.Tagger.When, nil
 }
 
 func getCommitObjectForTag(repo *git.Repository, tagRef *plumbing.Reference) (*object.Commit, error) {
@@ -6,6 +6,8 @@ require (
 	github.com/Masterminds/semver/v3 v3.4.0
 	github.com/alecthomas/chroma/v2 v2.23.1
 	github.com/go-git/go-git/v6 v6.0.0-20250819122726-39261590f7f3
+	github.com/google/uuid v1.6.0
+	github.com/ttab/newsdoc v0.7.4
 	github.com/ttab/revisor v0.9.4
 	github.com/urfave/cli/v3 v3.6.2
 	github.com/yoheimuta/go-protoparser/v4 v4.14.2
@@ -29,11 +31,9 @@ require (
 	github.com/go-git/go-billy/v6 v6.0.0-20250627091229-31e2a16eef30 // indirect
 	github.com/gobwas/glob v0.2.3 // indirect
 	github.com/golang/groupcache v0.0.0-20241129210726-2c02b8208cf8 // indirect
-	github.com/google/uuid v1.6.0 // indirect
 	github.com/kevinburke/ssh_config v1.2.0 // indirect
 	github.com/pjbgf/sha1cd v0.4.0 // indirect
 	github.com/sergi/go-diff v1.4.0 // indirect
-	github.com/ttab/newsdoc v0.7.4 // indirect
 	golang.org/x/crypto v0.41.0 // indirect
 	golang.org/x/exp v0.0.0-20250531010427-b6e5de432a8b // indirect
 	golang.org/x/sys v0.35.0 // indirect
@@ -0,0 +1,130 @@
+package internal
+
+import (
+	"path"
+	"strings"
+
+	"github.com/go-git/go-git/v6/plumbing/object"
+)
+
+// CommitFilter decides if a commit should be included based on the commit and
+// the names of the files that it changed.
+type CommitFilter func(c *object.Commit, names []string) bool
+
+// Or returns a filter that includes commits that match any of the filters.
+func Or(filters ...CommitFilter) CommitFilter {
+	return func(c *object.Commit, names []string) bool {
+		for _, f := range filters {
+			if f(c, names) {
+				return true
+			}
+		}
+
+		return false
+	}
+}
+
+// And returns a filter that includes commits that match all of the filters.
+func And(filters ...CommitFilter) CommitFilter {
+	return func(c *object.Commit, names []string) bool {
+		for _, f := range filters {
+			if !f(c, names) {
+				return false
+			}
+		}
+
+		return true
+	}
+}
+
+// Not returns a filter that includes the commits that the filter excludes.
+func Not(filter CommitFilter) CommitFilter {
+	return func(c *object.Commit, names []string) bool {
+		return !filter(c, names)
+	}
+}
+
+// AnyPath returns a filter that includes commits that changed any file that
+// match.
+func AnyPath(match func(name string) bool) CommitFilter {
+	return func(_ *object.Commit, names []string) bool {
+		for _, n := range names {
+			if match(n) {
+				return true
+			}
+		}
+
+		return false
+	}
+}
+
+// Record returns a filter that calls fn with the result of the filter for
+// each commit, used to keep track of why a commit was included.
+func Record(
+	filter CommitFilter, fn func(c *object.Commit, matched bool),
+) CommitFilter {
+	return func(c *object.Commit, names []string) bool {
+		ok := filter(c, names)
+
+		fn(c, ok)
+
+		return ok
+	}
+}
+
+// GlobMatcher returns a function that matches names against any of the glob
+// patterns. Patterns use the syntax of path.Match, with the addition of "**"
+// segments that match any number of directories. A pattern that ends with
+// "/" matches everything in the directory.
+func GlobMatcher(patterns []string) func(name string) bool {
+	return func(name string) bool {
+		for _, p := range patterns {
+			if MatchGlob(p, name) {
+				return true
+			}
+		}
+
+		return false
+	}
+}
+
+// MatchGlob matches a slash separated name against a glob pattern, see
+// GlobMatcher.
+func MatchGlob(pattern string, name string) bool {
+	if strings.HasSuffix(pattern, "/") {
+		pattern += "**"
+	}
+
+	return matchSegments(
+		strings.Split(pattern, "/"),
+		strings.Split(name, "/"))
+}
+
+func matchSegments(pattern []string, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			rest := pattern[1:]
+
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(rest, name[i:]) {
+					return true
+				}
+			}
+
+			return false
+		}
+
+		if len(name) == 0 {
+			return false
+		}
+
+		ok, err := path.Match(pattern[0], name[0])
+		if err != nil || !ok {
+			return false
+		}
+
+		pattern, name = pattern[1:], name[1:]
+	}
+
+	return len(name) == 0
+}
@@ -0,0 +1,119 @@
+package internal
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+)
+
+// NetrcEntry is a set of credentials for a machine in a netrc file.
+type NetrcEntry struct {
+	Login    string
+	Password string
+}
+
+// NetrcLookup reads the netrc file at path and returns the credentials for
+// host. The "default" entry is used if there is no entry for the host. The
+// returned boolean is false if no matching entry was found.
+func NetrcLookup(path string, host string) (NetrcEntry, bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return NetrcEntry{}, false, fmt.Errorf("open netrc file: %w", err)
+	}
+
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Split(bufio.ScanWords)
+
+	var (
+		entries  = make(map[string]NetrcEntry)
+		current  *NetrcEntry
+		machine  string
+		fallback *NetrcEntry
+	)
+
+	flush := func() {
+		if current == nil {
+			return
+		}
+
+		if machine == "" {
+			e := *current
+			fallback = &e
+		} else if _, exists := entries[machine]; !exists {
+			entries[machine] = *current
+		}
+
+		current = nil
+	}
+
+	for scanner.Scan() {
+		switch scanner.Text() {
+		case "machine":
+			flush()
+
+			if !scanner.Scan() {
+				return NetrcEntry{}, false, fmt.Errorf(
+					"malformed netrc file: missing machine name")
+			}
+
+			machine = scanner.Text()
+			current = &NetrcEntry{}
+		case "default":
+			flush()
+
+			machine = ""
+			current = &NetrcEntry{}
+		case "login":
+			if current == nil || !scanner.Scan() {
+				return NetrcEntry{}, false, fmt.Errorf(
+					"malformed netrc file: unexpected login")
+			}
+
+			current.Login = scanner.Text()
+		case "password":
+			if current == nil || !scanner.Scan() {
+				return NetrcEntry{}, false, fmt.Errorf(
+					"malformed netrc file: unexpected password")
+			}
+
+			current.Password = scanner.Text()
+		case "account":
+			// Accounts are not used for git authentication, skip
+			// the value.
+			scanner.Scan()
+		case "macdef":
+			// Macro definitions run until the next empty line,
+			// which the word scanner cannot see. Stop parsing, as
+			// netrc files conventionally place macros last.
+			flush()
+
+			return netrcResult(entries, fallback, host)
+		}
+	}
+
+	err = scanner.Err()
+	if err != nil {
+		return NetrcEntry{}, false, fmt.Errorf("read netrc file: %w", err)
+	}
+
+	flush()
+
+	return netrcResult(entries, fallback, host)
+}
+
+func netrcResult(
+	entries map[string]NetrcEntry, fallback *NetrcEntry, host string,
+) (NetrcEntry, bool, error) {
+	e, ok := entries[host]
+	if ok {
+		return e, true, nil
+	}
+
+	if fallback != nil {
+		return *fallback, true, nil
+	}
+
+	return NetrcEntry{}, false, nil
+}
@@ -10,14 +10,12 @@ import (
 // Adapted from github.com/go-git/go-git/v6/object commitPathIter to allow for
 // filtering by all the paths in the diff and the commit.
 //
-// TODO: One limitation of the iterator pattern and the iteratorsthat are
-// provided by go-git is that they not very composable. Or rather they only
-// composes in one way, as successive filters: X && Y && Z. So we cannot express
-// (X || Y) like I wanted to do by including commits if they matched a path
-// prefix or if they were tagged. Out of scope, but would be interesting to
-// think about ways to improve this.
+// The iterators provided by go-git only compose as successive filters: X && Y
+// && Z. Filters are instead composed as CommitFilter values, see Or, And and
+// Not, so that we can express things like (X || Y) when including commits if
+// they matched a path or if they were tagged.
 type commitPathIter struct {
-	pathFilter    func(*object.Commit, []string) bool
+	pathFilter    CommitFilter
 	sourceIter    object.CommitIter
 	currentCommit *object.Commit
 }
@@ -27,9 +25,10 @@ type commitPathIter struct {
 // to find the commits that explain how the files that match the path came to be.
 // If checkParent is true then the function double checks if potential parent (next commit in a path)
 // is one of the parents in the tree (it's used by `git log --all`).
-// pathFilter is a function that takes path of file as argument and returns true if we want it
+// pathFilter is a function that takes the commit and the paths of the changed files as
+// arguments and returns true if we want it.
 func NewCommitPathIterFromIter(
-	pathFilter func(*object.Commit, []string) bool,
+	pathFilter CommitFilter,
 	commitIter object.CommitIter,
 ) object.CommitIter {
 	iterator := new(commitPathIter)
@@ -122,6 +121,11 @@ func (c *commitPathIter) hasFileChange(changes object.Changes) bool {
 		}
 
 		names = append(names, name)
+
+		// Include both names of moved files.
+		if change.To.Name != "" && change.To.Name != name {
+			names = append(names, change.To.Name)
+		}
 	}
 
 	return c.pathFilter(c.currentCommit, names)
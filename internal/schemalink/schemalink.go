@@ -0,0 +1,220 @@
+// Package schemalink links the entities of validation results to the pages of
+// the schema documentation. It's shared by the validate command and the
+// WebAssembly module of the validation playground, and must not depend on
+// anything that can't be compiled to WebAssembly.
+package schemalink
+
+import (
+	"github.com/ttab/newsdoc"
+	"github.com/ttab/revisor"
+)
+
+// Index describes the document types of a schema version and the pages that
+// document them.
+type Index struct {
+	// Overview is the overview page of the schema version, used for
+	// document types that aren't declared.
+	Overview  string              `json:"overview"`
+	Documents map[string]Document `json:"documents"`
+	// Enums maps enum IDs to their pages.
+	Enums map[string]string `json:"enums,omitempty"`
+}
+
+// Document is a declared document type.
+type Document struct {
+	HRef       string                `json:"href"`
+	Attributes revisor.ConstraintMap `json:"attributes"`
+	Meta       []Block               `json:"meta,omitempty"`
+	Links      []Block               `json:"links,omitempty"`
+	Content    []Block               `json:"content,omitempty"`
+}
+
+// Block is a top level block declaration of a document type.
+type Block struct {
+	HRef       string                  `json:"href"`
+	Constraint revisor.BlockConstraint `json:"constraint"`
+}
+
+// Link finds the page that documents the entity that a validation result
+// refers to. Entity refs are ordered from the innermost entity and out. The
+// blocks are looked up in the document so that declarations can be matched
+// on role, which isn't part of the entity refs.
+func (idx Index) Link(doc *newsdoc.Document, entity []revisor.EntityRef) string {
+	d, ok := idx.Documents[doc.Type]
+	if !ok {
+		return idx.Overview
+	}
+
+	var (
+		outer      *Block
+		attributes = []revisor.ConstraintMap{d.Attributes}
+		data       revisor.ConstraintMap
+		nested     []revisor.BlockConstraint
+		parent     *newsdoc.Block
+	)
+
+	for i := len(entity) - 1; i >= 0; i-- {
+		ref := entity[i]
+
+		if ref.RefType != revisor.RefTypeBlock {
+			maps := attributes
+			if ref.RefType == revisor.RefTypeData {
+				maps = []revisor.ConstraintMap{data}
+			}
+
+			for _, m := range maps {
+				sc, ok := m.Constraints[ref.Name]
+				if ok && sc.EnumRef != "" && idx.Enums[sc.EnumRef] != "" {
+					return idx.Enums[sc.EnumRef]
+				}
+			}
+
+			break
+		}
+
+		var (
+			block *newsdoc.Block
+			bc    *revisor.BlockConstraint
+		)
+
+		// Nested blocks can only be looked up if their parent was found.
+		if outer == nil || parent != nil {
+			block = childBlock(doc, parent, ref)
+		}
+
+		sig := signature(ref, block)
+
+		if outer == nil {
+			outer = findBlock(d, ref.BlockKind, sig)
+			if outer == nil {
+				return d.HRef
+			}
+
+			bc = &outer.Constraint
+		} else {
+			bc = findNestedBlock(nested, sig)
+			if bc == nil {
+				break
+			}
+		}
+
+		parent = block
+		attributes = []revisor.ConstraintMap{bc.Attributes, bc.Match}
+		data = bc.Data
+
+		switch ref.BlockKind {
+		case revisor.BlockKindMeta:
+			nested = nestedConstraints(bc.Meta)
+		case revisor.BlockKindLink:
+			nested = nestedConstraints(bc.Links)
+		case revisor.BlockKindContent:
+			nested = nestedConstraints(bc.Content)
+		}
+	}
+
+	if outer == nil {
+		return d.HRef
+	}
+
+	return outer.HRef
+}
+
+// childBlock finds the block that an entity ref points to in the document, or
+// among the child blocks of parent if it's set.
+func childBlock(
+	doc *newsdoc.Document, parent *newsdoc.Block, ref revisor.EntityRef,
+) *newsdoc.Block {
+	var blocks []newsdoc.Block
+
+	switch {
+	case parent == nil && ref.BlockKind == revisor.BlockKindMeta:
+		blocks = doc.Meta
+	case parent == nil && ref.BlockKind == revisor.BlockKindLink:
+		blocks = doc.Links
+	case parent == nil && ref.BlockKind == revisor.BlockKindContent:
+		blocks = doc.Content
+	case ref.BlockKind == revisor.BlockKindMeta:
+		blocks = parent.Meta
+	case ref.BlockKind == revisor.BlockKindLink:
+		blocks = parent.Links
+	case ref.BlockKind == revisor.BlockKindContent:
+		blocks = parent.Content
+	}
+
+	if ref.Index < 0 || ref.Index >= len(blocks) {
+		return nil
+	}
+
+	return &blocks[ref.Index]
+}
+
+// signature returns the type, rel and role of the block that an entity ref
+// points to. The role is left out if the block couldn't be found.
+func signature(ref revisor.EntityRef, block *newsdoc.Block) revisor.BlockSignature {
+	if block == nil {
+		return revisor.BlockSignature{Type: ref.Type, Rel: ref.Rel}
+	}
+
+	return revisor.BlockSignature{
+		Type: block.Type,
+		Rel:  block.Rel,
+		Role: block.Role,
+	}
+}
+
+func findBlock(d Document, kind revisor.BlockKind, sig revisor.BlockSignature) *Block {
+	var blocks []Block
+
+	switch kind {
+	case revisor.BlockKindMeta:
+		blocks = d.Meta
+	case revisor.BlockKindLink:
+		blocks = d.Links
+	case revisor.BlockKindContent:
+		blocks = d.Content
+	}
+
+	for i := range blocks {
+		if declares(blocks[i].Constraint, sig) {
+			return &blocks[i]
+		}
+	}
+
+	return nil
+}
+
+func findNestedBlock(
+	blocks []revisor.BlockConstraint, sig revisor.BlockSignature,
+) *revisor.BlockConstraint {
+	for i := range blocks {
+		if declares(blocks[i], sig) {
+			return &blocks[i]
+		}
+	}
+
+	return nil
+}
+
+// declares checks if a block constraint declares a block signature.
+// Declarations that leave out the type, rel or role match any value.
+func declares(bc revisor.BlockConstraint, sig revisor.BlockSignature) bool {
+	if bc.Declares == nil {
+		return false
+	}
+
+	return (bc.Declares.Type == "" || bc.Declares.Type == sig.Type) &&
+		(bc.Declares.Rel == "" || bc.Declares.Rel == sig.Rel) &&
+		(bc.Declares.Role == "" || bc.Declares.Role == sig.Role)
+}
+
+func nestedConstraints(blocks []*revisor.BlockConstraint) []revisor.BlockConstraint {
+	var constraints []revisor.BlockConstraint
+
+	for _, b := range blocks {
+		if b != nil {
+			constraints = append(constraints, *b)
+		}
+	}
+
+	return constraints
+}
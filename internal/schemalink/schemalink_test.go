@@ -0,0 +1,106 @@
+package schemalink
+
+import (
+	"testing"
+
+	"github.com/ttab/newsdoc"
+	"github.com/ttab/revisor"
+)
+
+func TestIndexLinkMatchesRole(t *testing.T) {
+	title := revisor.ConstraintMap{
+		Keys: []string{"value"},
+		Constraints: map[string]revisor.StringConstraint{
+			"value": {EnumRef: "test://status"},
+		},
+	}
+
+	index := Index{
+		Overview: "/schemas/",
+		Documents: map[string]Document{
+			"test/doc": {
+				HRef: "/schemas/documents/test/doc/",
+				Meta: []Block{
+					{
+						HRef: "/schemas/documents/test/doc/#block-test-meta-main",
+						Constraint: revisor.BlockConstraint{
+							Declares: &revisor.BlockSignature{
+								Type: "test/meta",
+								Role: "main",
+							},
+						},
+					},
+					{
+						HRef: "/schemas/documents/test/doc/#block-test-meta-other",
+						Constraint: revisor.BlockConstraint{
+							Declares: &revisor.BlockSignature{
+								Type: "test/meta",
+								Role: "other",
+							},
+							Attributes: title,
+						},
+					},
+				},
+			},
+		},
+		Enums: map[string]string{
+			"test://status": "/schemas/enums/test/status/",
+		},
+	}
+
+	doc := newsdoc.Document{
+		Type: "test/doc",
+		Meta: []newsdoc.Block{
+			{Type: "test/meta", Role: "main"},
+			{Type: "test/meta", Role: "other"},
+		},
+	}
+
+	blockRef := func(i int) revisor.EntityRef {
+		return revisor.EntityRef{
+			RefType:   revisor.RefTypeBlock,
+			BlockKind: revisor.BlockKindMeta,
+			Index:     i,
+			Type:      "test/meta",
+		}
+	}
+
+	cases := map[string]struct {
+		entity []revisor.EntityRef
+		want   string
+	}{
+		"first role": {
+			entity: []revisor.EntityRef{blockRef(0)},
+			want:   "/schemas/documents/test/doc/#block-test-meta-main",
+		},
+		"second role": {
+			entity: []revisor.EntityRef{blockRef(1)},
+			want:   "/schemas/documents/test/doc/#block-test-meta-other",
+		},
+		"enum attribute": {
+			entity: []revisor.EntityRef{
+				{RefType: revisor.RefTypeAttribute, Name: "value"},
+				blockRef(1),
+			},
+			want: "/schemas/enums/test/status/",
+		},
+		"document": {
+			entity: []revisor.EntityRef{
+				{RefType: revisor.RefTypeAttribute, Name: "title"},
+			},
+			want: "/schemas/documents/test/doc/",
+		},
+	}
+
+	for name, c := range cases {
+		got := index.Link(&doc, c.entity)
+		if got != c.want {
+			t.Errorf("%s: got %q, want %q", name, got, c.want)
+		}
+	}
+
+	got := index.Link(&newsdoc.Document{Type: "test/unknown"}, nil)
+	if got != index.Overview {
+		t.Errorf("got %q for an unknown document type, want the overview", got)
+	}
+}
@@ -0,0 +1,863 @@
+package elephantdocs
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"regexp/syntax"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/ttab/revisor"
+)
+
+// jsonSchemaDialect is the JSON Schema draft that the exported schemas use.
+const jsonSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// JSONSchema is the subset of JSON Schema that is used to describe NewsDoc
+// documents.
+type JSONSchema struct {
+	Schema               string                 `json:"$schema,omitempty"`
+	Ref                  string                 `json:"$ref,omitempty"`
+	Title                string                 `json:"title,omitempty"`
+	Description          string                 `json:"description,omitempty"`
+	Deprecated           bool                   `json:"deprecated,omitempty"`
+	Type                 string                 `json:"type,omitempty"`
+	Const                *string                `json:"const,omitempty"`
+	Enum                 []string               `json:"enum,omitempty"`
+	Format               string                 `json:"format,omitempty"`
+	Pattern              string                 `json:"pattern,omitempty"`
+	Comment              string                 `json:"$comment,omitempty"`
+	MinLength            *int                   `json:"minLength,omitempty"`
+	ContentMediaType     string                 `json:"contentMediaType,omitempty"`
+	Properties           map[string]*JSONSchema `json:"properties,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	AdditionalProperties *bool                  `json:"additionalProperties,omitempty"`
+	Items                *JSONSchema            `json:"items,omitempty"`
+	MaxItems             *int                   `json:"maxItems,omitempty"`
+	Contains             *JSONSchema            `json:"contains,omitempty"`
+	MinContains          *int                   `json:"minContains,omitempty"`
+	MaxContains          *int                   `json:"maxContains,omitempty"`
+	AllOf                []*JSONSchema          `json:"allOf,omitempty"`
+	AnyOf                []*JSONSchema          `json:"anyOf,omitempty"`
+	OneOf                []*JSONSchema          `json:"oneOf,omitempty"`
+	If                   *JSONSchema            `json:"if,omitempty"`
+	Then                 *JSONSchema            `json:"then,omitempty"`
+	Defs                 map[string]*JSONSchema `json:"$defs,omitempty"`
+}
+
+// blockAttributeNames are the block attributes that must be empty unless they
+// have been declared. The "id" attribute isn't checked by revisor.
+var blockAttributeNames = []string{
+	"uuid", "type", "uri", "url", "title", "rel", "name", "value",
+	"contenttype", "role", "sensitivity",
+}
+
+// documentAttributeNames are the attributes of a document. Unlike block
+// attributes, revisor only checks the document attributes that have
+// constraints, so the others can have any value. The uuid must always be a
+// valid UUID.
+var documentAttributeNames = []string{
+	"uuid", "type", "uri", "url", "title", "language",
+}
+
+// Patterns for the string formats that can't be expressed as a JSON Schema
+// format.
+const (
+	intPattern   = `^[+-]?[0-9]+$`
+	floatPattern = `^[+-]?([0-9]+(\.[0-9]*)?|\.[0-9]+)([eE][+-]?[0-9]+)?$`
+)
+
+var boolValues = []string{
+	"1", "t", "T", "TRUE", "true", "True",
+	"0", "f", "F", "FALSE", "false", "False",
+}
+
+// documentJSONSchema describes a document type as a standalone JSON Schema,
+// with the enums that it references in "$defs".
+func documentJSONSchema(d DocumentDoc, enums []EnumDoc) *JSONSchema {
+	g := newJSONSchemaGenerator(enums)
+
+	s := g.document(d)
+
+	s.Schema = jsonSchemaDialect
+	s.Defs = g.defs
+
+	return s
+}
+
+// bundleJSONSchema describes all declared document types of a schema version
+// as a single JSON Schema that accepts a document of any of the types.
+func bundleJSONSchema(doc *SchemaDoc, version string) *JSONSchema {
+	g := newJSONSchemaGenerator(doc.Enums)
+
+	bundle := JSONSchema{
+		Schema:      jsonSchemaDialect,
+		Title:       "NewsDoc documents",
+		Description: "Document types of schema version " + version,
+		Defs:        g.defs,
+	}
+
+	for _, d := range doc.Documents {
+		if d.DeclaredIn == "" {
+			continue
+		}
+
+		g.defs[d.Type] = g.document(d)
+
+		bundle.OneOf = append(bundle.OneOf, &JSONSchema{
+			Ref: jsonSchemaDefRef(d.Type),
+		})
+	}
+
+	return &bundle
+}
+
+// jsonSchemaDefRef references a definition in "$defs" using a JSON pointer.
+func jsonSchemaDefRef(name string) string {
+	name = strings.ReplaceAll(name, "~", "~0")
+	name = strings.ReplaceAll(name, "/", "~1")
+
+	return "#/$defs/" + name
+}
+
+type jsonSchemaGenerator struct {
+	enums map[string]EnumDoc
+	defs  map[string]*JSONSchema
+}
+
+func newJSONSchemaGenerator(enums []EnumDoc) *jsonSchemaGenerator {
+	g := jsonSchemaGenerator{
+		enums: make(map[string]EnumDoc, len(enums)),
+		defs:  make(map[string]*JSONSchema),
+	}
+
+	for _, e := range enums {
+		g.enums[e.ID] = e
+	}
+
+	return &g
+}
+
+func (g *jsonSchemaGenerator) document(d DocumentDoc) *JSONSchema {
+	s := JSONSchema{
+		Title:       docDisplayName(d),
+		Description: d.Description,
+		Deprecated:  d.Deprecated != nil,
+		Type:        "object",
+		Properties:  make(map[string]*JSONSchema),
+		Required:    []string{"type"},
+	}
+
+	for _, k := range documentAttributeNames {
+		sc, ok := d.Attributes.Constraints[k]
+
+		switch {
+		case k == "type":
+			s.Properties[k] = &JSONSchema{Type: "string", Const: &d.Type}
+		case k == "uuid":
+			// The constraints of a declared uuid attribute
+			// apply on top of the UUID check, which doesn't
+			// allow it to be empty.
+			u := JSONSchema{Type: "string", Format: "uuid"}
+
+			if ok {
+				sc.AllowEmpty = false
+				u.AllOf = []*JSONSchema{g.value(sc)}
+			}
+
+			s.Properties[k] = &u
+			s.Required = append(s.Required, k)
+		case ok:
+			s.Properties[k] = g.value(sc)
+
+			if !sc.AllowEmpty {
+				s.Required = append(s.Required, k)
+			}
+		default:
+			s.Properties[k] = &JSONSchema{Type: "string"}
+		}
+	}
+
+	s.Properties["meta"] = g.blockList(resolvedConstraints(d.Meta))
+	s.Properties["links"] = g.blockList(resolvedConstraints(d.Links))
+	s.Properties["content"] = g.blockList(resolvedConstraints(d.Content))
+
+	return &s
+}
+
+// blockList describes a list of blocks. Every block must match one of the
+// declarations, and match constraints extend the declarations that they can
+// match, the same way as in the examples.
+func (g *jsonSchemaGenerator) blockList(constraints []revisor.BlockConstraint) *JSONSchema {
+	var (
+		declarations []*JSONSchema
+		counts       []*JSONSchema
+		extensions   = blockExtensions(constraints)
+	)
+
+	for _, bc := range constraints {
+		if bc.Declares != nil {
+			declarations = append(declarations, g.block(bc, extensions))
+		}
+
+		if c := blockCountSchema(bc); c != nil {
+			counts = append(counts, c)
+		}
+	}
+
+	s := JSONSchema{
+		Type:  "array",
+		AllOf: counts,
+	}
+
+	if len(declarations) == 0 {
+		s.MaxItems = new(int)
+	} else {
+		s.Items = &JSONSchema{AnyOf: declarations}
+	}
+
+	return &s
+}
+
+// blockCountSchema describes the count constraints of a block constraint as
+// the number of blocks that must match its signature.
+func blockCountSchema(bc revisor.BlockConstraint) *JSONSchema {
+	if bc.Count == nil && bc.MinCount == nil && bc.MaxCount == nil {
+		return nil
+	}
+
+	s := JSONSchema{
+		Contains:    blockMatchSchema(blockSignatureConstraints(bc)),
+		MinContains: new(int),
+		MaxContains: bc.MaxCount,
+	}
+
+	switch {
+	case bc.Count != nil:
+		s.MinContains = bc.Count
+		s.MaxContains = bc.Count
+	case bc.MinCount != nil:
+		s.MinContains = bc.MinCount
+	}
+
+	return &s
+}
+
+// extendedBlock is a block declaration merged with the match constraints that
+// always apply to it.
+type extendedBlock struct {
+	Attributes revisor.ConstraintMap
+	Data       revisor.ConstraintMap
+	// Conditional are the match constraints that only apply to some
+	// of the declared blocks.
+	Conditional []revisor.BlockConstraint
+}
+
+// blockExtensions returns the match constraints in a list of block
+// constraints.
+func blockExtensions(constraints []revisor.BlockConstraint) []revisor.BlockConstraint {
+	var extensions []revisor.BlockConstraint
+
+	for _, bc := range constraints {
+		if bc.Declares == nil && len(bc.Match.Keys) > 0 {
+			extensions = append(extensions, bc)
+		}
+	}
+
+	return extensions
+}
+
+func extendBlock(
+	bc revisor.BlockConstraint, extensions []revisor.BlockConstraint,
+) extendedBlock {
+	eb := extendedBlock{
+		Attributes: blockSignatureConstraints(bc).Copy(),
+		Data:       bc.Data.Copy(),
+	}
+
+	mergeConstraintMap(&eb.Attributes, bc.Attributes)
+
+	for _, ext := range extensions {
+		switch {
+		case matchImplied(ext.Match, eb.Attributes):
+			mergeConstraintMap(&eb.Attributes, ext.Match)
+			mergeConstraintMap(&eb.Attributes, ext.Attributes)
+			mergeConstraintMap(&eb.Data, ext.Data)
+		case matchPossible(ext.Match, eb.Attributes):
+			eb.Conditional = append(eb.Conditional, ext)
+		}
+	}
+
+	return eb
+}
+
+// blockSignatureConstraints returns the attribute constraints that a block
+// must match for a block constraint to apply to it.
+func blockSignatureConstraints(bc revisor.BlockConstraint) revisor.ConstraintMap {
+	if bc.Declares == nil {
+		return bc.Match
+	}
+
+	m := bc.Declares.AsConstraint()
+
+	mergeConstraintMap(&m, bc.Match)
+
+	return m
+}
+
+// blockMatchSchema describes the const and enum constraints of a match.
+func blockMatchSchema(match revisor.ConstraintMap) *JSONSchema {
+	s := JSONSchema{
+		Properties: make(map[string]*JSONSchema),
+	}
+
+	for _, k := range match.Keys {
+		sc := match.Constraints[k]
+
+		switch {
+		case sc.Const != nil:
+			s.Properties[k] = &JSONSchema{Const: sc.Const}
+		case len(sc.Enum) > 0:
+			s.Properties[k] = &JSONSchema{Enum: sc.Enum}
+		default:
+			continue
+		}
+
+		s.Required = append(s.Required, k)
+	}
+
+	return &s
+}
+
+func (g *jsonSchemaGenerator) block(
+	bc revisor.BlockConstraint, extensions []revisor.BlockConstraint,
+) *JSONSchema {
+	eb := extendBlock(bc, extensions)
+
+	s := JSONSchema{
+		Title:       bc.Name,
+		Description: bc.Description,
+		Deprecated:  bc.Deprecated != nil,
+		Type:        "object",
+		Properties:  make(map[string]*JSONSchema),
+	}
+
+	// Extensions that don't always apply are added as conditions, and
+	// the keys that they declare are allowed but not required.
+	var (
+		conditional     []string
+		conditionalData []string
+	)
+
+	for _, ext := range eb.Conditional {
+		then := JSONSchema{Properties: make(map[string]*JSONSchema)}
+
+		g.addProperties(&then, ext.Attributes, true)
+
+		if len(ext.Data.Keys) > 0 {
+			extData := JSONSchema{
+				Properties: make(map[string]*JSONSchema),
+			}
+
+			g.addProperties(&extData, ext.Data, false)
+
+			then.Properties["data"] = &extData
+			then.Required = append(then.Required, "data")
+		}
+
+		s.AllOf = append(s.AllOf, &JSONSchema{
+			If:   blockMatchSchema(ext.Match),
+			Then: &then,
+		})
+
+		conditional = append(conditional, ext.Attributes.Keys...)
+		conditionalData = append(conditionalData, ext.Data.Keys...)
+	}
+
+	attributes := eb.Attributes
+	data := eb.Data
+
+	g.addProperties(&s, attributes, true)
+
+	empty := ""
+
+	for _, k := range blockAttributeNames {
+		if s.Properties[k] != nil {
+			continue
+		}
+
+		if slices.Contains(conditional, k) {
+			s.Properties[k] = &JSONSchema{Type: "string"}
+		} else {
+			s.Properties[k] = &JSONSchema{Const: &empty}
+		}
+	}
+
+	if s.Properties["id"] == nil {
+		s.Properties["id"] = &JSONSchema{Type: "string"}
+	}
+
+	dataSchema := JSONSchema{
+		Type:                 "object",
+		Properties:           make(map[string]*JSONSchema),
+		AdditionalProperties: new(bool),
+	}
+
+	g.addProperties(&dataSchema, data, false)
+
+	for _, k := range conditionalData {
+		if dataSchema.Properties[k] == nil {
+			dataSchema.Properties[k] = &JSONSchema{Type: "string"}
+		}
+	}
+
+	s.Properties["data"] = &dataSchema
+
+	if len(dataSchema.Required) > 0 {
+		s.Required = append(s.Required, "data")
+	}
+
+	s.Properties["meta"] = g.blockList(nestedConstraints(bc.Meta))
+	s.Properties["links"] = g.blockList(nestedConstraints(bc.Links))
+	s.Properties["content"] = g.blockList(nestedConstraints(bc.Content))
+
+	return &s
+}
+
+// addProperties adds the constraints of a constraint map as properties.
+// Attributes always exist, so they are required unless they are allowed to
+// be empty.
+func (g *jsonSchemaGenerator) addProperties(
+	s *JSONSchema, m revisor.ConstraintMap, attributes bool,
+) {
+	for _, k := range m.Keys {
+		sc := m.Constraints[k]
+
+		s.Properties[k] = g.value(sc)
+
+		optional := sc.Optional
+		if attributes {
+			optional = sc.AllowEmpty
+		}
+
+		if !optional {
+			s.Required = append(s.Required, k)
+		}
+	}
+}
+
+// value describes a string constraint.
+func (g *jsonSchemaGenerator) value(sc revisor.StringConstraint) *JSONSchema {
+	s := JSONSchema{
+		Type:  "string",
+		Const: sc.Const,
+		Enum:  sc.Enum,
+	}
+
+	if sc.EnumRef != "" {
+		s.Ref = g.enumRef(sc.EnumRef)
+	}
+
+	if sc.Pattern != nil {
+		s.Pattern, s.Comment = jsonSchemaPattern(sc.Pattern.String())
+	}
+
+	for _, gl := range sc.Glob {
+		var glob JSONSchema
+
+		glob.Pattern, glob.Comment = jsonSchemaPattern(
+			globRegexp(globPattern(gl)))
+
+		s.AnyOf = append(s.AnyOf, &glob)
+	}
+
+	switch sc.Format {
+	case revisor.StringFormatRFC3339:
+		s.Format = "date-time"
+	case revisor.StringFormatUUID:
+		s.Format = "uuid"
+	case revisor.StringFormatHTML:
+		s.ContentMediaType = "text/html"
+	case revisor.StringFormatInt:
+		s.AllOf = append(s.AllOf, &JSONSchema{Pattern: intPattern})
+	case revisor.StringFormatFloat:
+		s.AllOf = append(s.AllOf, &JSONSchema{Pattern: floatPattern})
+	case revisor.StringFormatBoolean:
+		s.AllOf = append(s.AllOf, &JSONSchema{Enum: boolValues})
+	}
+
+	if sc.Const == nil && len(sc.Enum) == 0 && s.Ref == "" {
+		minLength := 1
+		s.MinLength = &minLength
+	}
+
+	description := sc.Description
+	if description == "" {
+		description = sc.Name
+	}
+
+	if !sc.AllowEmpty {
+		s.Description = description
+		s.Deprecated = sc.Deprecated != nil
+
+		return &s
+	}
+
+	empty := ""
+
+	return &JSONSchema{
+		Description: description,
+		Deprecated:  sc.Deprecated != nil,
+		AnyOf:       []*JSONSchema{{Const: &empty}, &s},
+	}
+}
+
+// enumRef adds an enum to the definitions and returns a reference to it.
+// Forbidden values are left out.
+func (g *jsonSchemaGenerator) enumRef(id string) string {
+	ref := jsonSchemaDefRef(id)
+
+	if g.defs[id] != nil {
+		return ref
+	}
+
+	e, ok := g.enums[id]
+	if !ok {
+		return ""
+	}
+
+	s := JSONSchema{
+		Title:       enumDisplayName(e),
+		Description: e.Description,
+		Type:        "string",
+		Enum:        []string{},
+	}
+
+	for _, v := range e.Values {
+		if !v.Forbidden {
+			s.Enum = append(s.Enum, v.Value)
+		}
+	}
+
+	g.defs[id] = &s
+
+	return ref
+}
+
+// matchImplied checks if the attribute constraints of a block declaration
+// guarantee that a match applies.
+func matchImplied(match revisor.ConstraintMap, attributes revisor.ConstraintMap) bool {
+	for _, k := range match.Keys {
+		declared, ok := attributes.Constraints[k]
+		if !ok || declared.Const == nil {
+			return false
+		}
+
+		if !matchAccepts(match.Constraints[k], *declared.Const) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// matchPossible checks if a match can apply to a block that satisfies the
+// const constraints of a block declaration.
+func matchPossible(match revisor.ConstraintMap, attributes revisor.ConstraintMap) bool {
+	for _, k := range match.Keys {
+		declared, ok := attributes.Constraints[k]
+		if !ok || declared.Const == nil {
+			continue
+		}
+
+		if !matchAccepts(match.Constraints[k], *declared.Const) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func matchAccepts(sc revisor.StringConstraint, v string) bool {
+	switch {
+	case sc.Const != nil:
+		return *sc.Const == v
+	case len(sc.Enum) > 0:
+		return slices.Contains(sc.Enum, v)
+	}
+
+	return true
+}
+
+// globPattern returns the source pattern of a compiled glob.
+func globPattern(g *revisor.Glob) string {
+	data, err := g.MarshalJSON()
+	if err != nil {
+		return ""
+	}
+
+	var pattern string
+
+	err = json.Unmarshal(data, &pattern)
+	if err != nil {
+		return ""
+	}
+
+	return pattern
+}
+
+var globTokens = regexp.MustCompile(`\\.|\*\*|\*|\?|\{[^}]*\}|\[!?[^\]]*\]|.`)
+
+// globRegexp converts a glob pattern to an anchored regular expression. The
+// single character wildcards don't match the "/" and "+" separators, like in
+// revisor.
+func globRegexp(pattern string) string {
+	var b strings.Builder
+
+	b.WriteString("^")
+
+	for _, t := range globTokens.FindAllString(pattern, -1) {
+		switch {
+		case t == "**":
+			b.WriteString(".*")
+		case t == "*":
+			b.WriteString("[^/+]*")
+		case t == "?":
+			b.WriteString("[^/+]")
+		case strings.HasPrefix(t, "\\"):
+			b.WriteString(regexp.QuoteMeta(t[1:]))
+		case strings.HasPrefix(t, "{") && len(t) > 1:
+			alternatives := strings.Split(t[1:len(t)-1], ",")
+
+			for i := range alternatives {
+				alternatives[i] = globRegexp(alternatives[i])
+				alternatives[i] = alternatives[i][1 : len(alternatives[i])-1]
+			}
+
+			b.WriteString("(" + strings.Join(alternatives, "|") + ")")
+		case strings.HasPrefix(t, "[") && len(t) > 1:
+			class := t[1 : len(t)-1]
+
+			if rest, ok := strings.CutPrefix(class, "!"); ok {
+				class = "^" + rest
+			}
+
+			b.WriteString("[" + class + "]")
+		default:
+			b.WriteString(regexp.QuoteMeta(t))
+		}
+	}
+
+	b.WriteString("$")
+
+	return b.String()
+}
+
+// jsonSchemaPattern translates a Go regular expression to the ECMA-262 syntax
+// of JSON Schema patterns, which are matched with the "u" flag. Go accepts
+// syntax that ECMA-262 doesn't, like flags, \A, \z and POSIX classes, so the
+// expression is written out from its parsed form. An expression that can't be
+// parsed is returned as a comment instead.
+func jsonSchemaPattern(expr string) (string, string) {
+	re, err := syntax.Parse(expr, syntax.Perl)
+	if err != nil {
+		return "", fmt.Sprintf("the pattern %q can't be expressed: %v",
+			expr, err)
+	}
+
+	var b strings.Builder
+
+	writeECMAPattern(&b, re)
+
+	return b.String(), ""
+}
+
+func writeECMAPattern(b *strings.Builder, re *syntax.Regexp) {
+	switch re.Op {
+	case syntax.OpNoMatch:
+		b.WriteString("[]")
+	case syntax.OpEmptyMatch:
+		b.WriteString("(?:)")
+	case syntax.OpLiteral:
+		for _, r := range re.Rune {
+			if re.Flags&syntax.FoldCase != 0 {
+				writeECMAFold(b, r)
+			} else {
+				writeECMARune(b, r, false)
+			}
+		}
+	case syntax.OpCharClass:
+		writeECMAClass(b, re.Rune)
+	case syntax.OpAnyCharNotNL:
+		b.WriteString(`[^\n]`)
+	case syntax.OpAnyChar:
+		b.WriteString(`[\s\S]`)
+	case syntax.OpBeginLine:
+		b.WriteString(`(?<![^\n])`)
+	case syntax.OpEndLine:
+		b.WriteString(`(?![^\n])`)
+	case syntax.OpBeginText:
+		b.WriteString("^")
+	case syntax.OpEndText:
+		b.WriteString("$")
+	case syntax.OpWordBoundary:
+		b.WriteString(`\b`)
+	case syntax.OpNoWordBoundary:
+		b.WriteString(`\B`)
+	case syntax.OpCapture:
+		b.WriteString("(")
+		writeECMAPattern(b, re.Sub[0])
+		b.WriteString(")")
+	case syntax.OpStar, syntax.OpPlus, syntax.OpQuest, syntax.OpRepeat:
+		writeECMAAtom(b, re.Sub[0])
+
+		switch {
+		case re.Op == syntax.OpStar:
+			b.WriteString("*")
+		case re.Op == syntax.OpPlus:
+			b.WriteString("+")
+		case re.Op == syntax.OpQuest:
+			b.WriteString("?")
+		case re.Max == -1:
+			fmt.Fprintf(b, "{%d,}", re.Min)
+		case re.Min == re.Max:
+			fmt.Fprintf(b, "{%d}", re.Min)
+		default:
+			fmt.Fprintf(b, "{%d,%d}", re.Min, re.Max)
+		}
+
+		if re.Flags&syntax.NonGreedy != 0 {
+			b.WriteString("?")
+		}
+	case syntax.OpConcat:
+		for _, sub := range re.Sub {
+			if sub.Op == syntax.OpAlternate {
+				writeECMAGroup(b, sub)
+			} else {
+				writeECMAPattern(b, sub)
+			}
+		}
+	case syntax.OpAlternate:
+		for i, sub := range re.Sub {
+			if i > 0 {
+				b.WriteString("|")
+			}
+
+			writeECMAPattern(b, sub)
+		}
+	}
+}
+
+// writeECMAAtom writes an expression that a repetition applies to, grouping it
+// unless it matches a single character.
+func writeECMAAtom(b *strings.Builder, re *syntax.Regexp) {
+	switch {
+	case re.Op == syntax.OpLiteral && len(re.Rune) == 1,
+		re.Op == syntax.OpCharClass,
+		re.Op == syntax.OpAnyChar,
+		re.Op == syntax.OpAnyCharNotNL,
+		re.Op == syntax.OpCapture:
+		writeECMAPattern(b, re)
+	default:
+		writeECMAGroup(b, re)
+	}
+}
+
+func writeECMAGroup(b *strings.Builder, re *syntax.Regexp) {
+	b.WriteString("(?:")
+	writeECMAPattern(b, re)
+	b.WriteString(")")
+}
+
+// writeECMAFold writes a case-insensitive character as a class of its case
+// variants, as JSON Schema patterns can't have flags.
+func writeECMAFold(b *strings.Builder, r rune) {
+	variants := []rune{r}
+
+	for f := unicode.SimpleFold(r); f != r; f = unicode.SimpleFold(f) {
+		variants = append(variants, f)
+	}
+
+	if len(variants) == 1 {
+		writeECMARune(b, r, false)
+
+		return
+	}
+
+	b.WriteString("[")
+
+	for _, v := range variants {
+		writeECMARune(b, v, true)
+	}
+
+	b.WriteString("]")
+}
+
+// writeECMAClass writes a character class from its ranges. Classes that
+// include the last code point are written as a negation of the characters
+// that they leave out.
+func writeECMAClass(b *strings.Builder, ranges []rune) {
+	negated := len(ranges) > 0 && ranges[len(ranges)-1] == unicode.MaxRune
+
+	if negated {
+		var complement []rune
+
+		next := rune(0)
+
+		for i := 0; i < len(ranges); i += 2 {
+			if ranges[i] > next {
+				complement = append(complement, next, ranges[i]-1)
+			}
+
+			next = ranges[i+1] + 1
+		}
+
+		if len(complement) == 0 {
+			b.WriteString(`[\s\S]`)
+
+			return
+		}
+
+		ranges = complement
+	}
+
+	b.WriteString("[")
+
+	if negated {
+		b.WriteString("^")
+	}
+
+	for i := 0; i < len(ranges); i += 2 {
+		writeECMARune(b, ranges[i], true)
+
+		if ranges[i+1] != ranges[i] {
+			b.WriteString("-")
+			writeECMARune(b, ranges[i+1], true)
+		}
+	}
+
+	b.WriteString("]")
+}
+
+// writeECMARune writes a character, escaping the characters that have a
+// meaning in the pattern and the characters that aren't printable ASCII.
+func writeECMARune(b *strings.Builder, r rune, inClass bool) {
+	special := `\^$.|?*+()[]{}`
+	if inClass {
+		special = `\^-[]`
+	}
+
+	switch {
+	case r < 0x7f && strings.ContainsRune(special, r):
+		b.WriteString(`\`)
+		b.WriteRune(r)
+	case r > 0x20 && r < 0x7f:
+		b.WriteRune(r)
+	case r <= 0xffff:
+		fmt.Fprintf(b, `\u%04X`, r)
+	default:
+		fmt.Fprintf(b, `\u{%X}`, r)
+	}
+}
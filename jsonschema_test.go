@@ -0,0 +1,133 @@
+package elephantdocs
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/ttab/revisor"
+)
+
+func TestJSONSchemaPattern(t *testing.T) {
+	cases := map[string]string{
+		`^\d{4}-\d{2}$`:           `^[0-9]{4}-[0-9]{2}$`,
+		`(?i)ab`:                  `[Aa][Bb]`,
+		`\Aab|cd\z`:               `^ab|cd$`,
+		`[[:alpha:]]+`:            `[A-Za-z]+`,
+		`[^/]+`:                   `[^/]+`,
+		`(?m)^x$`:                 `(?<![^\n])x(?![^\n])`,
+		`(?s)a.b`:                 `a[\s\S]b`,
+		`(?P<name>a+?)(?:bc){2,}`: `(a+?)(?:bc){2,}`,
+		`é\x{1F600}`:              `\u00E9\u{1F600}`,
+		`[a-]|x{1,3}`:             `[\-a]|x{1,3}`,
+		`a(bc|de)f`:               `a(bc|de)f`,
+		`a(?:bc|de)f`:             `a(?:bc|de)f`,
+		`(?U)a+`:                  `a+?`,
+	}
+
+	for expr, want := range cases {
+		got, comment := jsonSchemaPattern(expr)
+		if comment != "" {
+			t.Errorf("%s: unexpected comment %q", expr, comment)
+		}
+
+		if got != want {
+			t.Errorf("%s: got %s, want %s", expr, got, want)
+		}
+	}
+
+	pattern, comment := jsonSchemaPattern(`a(b`)
+	if pattern != "" || comment == "" {
+		t.Errorf("got pattern %q and comment %q for an invalid expression",
+			pattern, comment)
+	}
+}
+
+func TestDocumentAttributesMatchTypeScript(t *testing.T) {
+	enum := "a"
+
+	doc := &SchemaDoc{
+		Documents: []DocumentDoc{
+			{
+				Type:       "test/doc",
+				DeclaredIn: "test",
+				Attributes: revisor.ConstraintMap{
+					Keys: []string{"uuid", "title"},
+					Constraints: map[string]revisor.StringConstraint{
+						"uuid":  {AllowEmpty: true},
+						"title": {Const: &enum},
+					},
+				},
+			},
+			{
+				Type:       "test/plain",
+				DeclaredIn: "test",
+			},
+		},
+	}
+
+	ts := schemaTypeScript(doc, "v1.0.0")
+
+	for _, d := range doc.Documents {
+		s := documentJSONSchema(d, nil)
+
+		// Revisor always requires a valid UUID.
+		if !slices.Contains(s.Required, "uuid") {
+			t.Errorf("%s: uuid isn't required in the JSON Schema", d.Type)
+		}
+
+		if s.Properties["uuid"].Format != "uuid" {
+			t.Errorf("%s: got uuid format %q", d.Type,
+				s.Properties["uuid"].Format)
+		}
+
+		// Undeclared document attributes aren't checked.
+		url := s.Properties["url"]
+		if url.Type != "string" || url.Const != nil || slices.Contains(s.Required, "url") {
+			t.Errorf("%s: got url property %+v, want any string", d.Type, url)
+		}
+	}
+
+	for _, want := range []string{
+		"export interface TestDoc {\n  uuid: string;\n",
+		"  title: \"a\";\n",
+		"export interface TestPlain {\n  uuid: string;\n",
+		"  url?: string;\n",
+	} {
+		if !strings.Contains(ts, want) {
+			t.Errorf("the TypeScript declarations don't contain %q:\n%s",
+				want, ts)
+		}
+	}
+}
+
+func TestJSONSchemaPatternMatchesGo(t *testing.T) {
+	// The translated patterns only use syntax that means the same in Go,
+	// so they can be checked against the original expressions.
+	inputs := []string{"abc", "ABC", "1234-12", "x\nx", "core/x", "-", "é"}
+
+	for _, expr := range []string{
+		`^\d{4}-\d{2}$`, `(?i)abc`, `[[:alpha:]]+`, `[^/]+`, `(?s).`,
+		`[a-]`, globRegexp("core/*"),
+	} {
+		pattern, _ := jsonSchemaPattern(expr)
+
+		translated, err := regexp.Compile(pattern)
+		if err != nil {
+			t.Errorf("%s: compile translated pattern %s: %v",
+				expr, pattern, err)
+
+			continue
+		}
+
+		original := regexp.MustCompile(expr)
+
+		for _, in := range inputs {
+			if original.MatchString(in) != translated.MatchString(in) {
+				t.Errorf("%s: the translated pattern %s differs for %q",
+					expr, pattern, in)
+			}
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package elephantdocs
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"slices"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/go-git/go-git/v6/plumbing/object"
+)
+
+// Version lifecycle statuses.
+const (
+	LifecycleDeprecated = "deprecated"
+	LifecycleEndOfLife  = "eol"
+	LifecycleYanked     = "yanked"
+)
+
+// VersionLifecycle is the lifecycle status of a version.
+type VersionLifecycle struct {
+	Status string
+	Reason string `json:",omitempty"`
+	// Replacement is the version that users should move to.
+	Replacement string `json:",omitempty"`
+}
+
+// Label returns a human readable status.
+func (l VersionLifecycle) Label() string {
+	switch l.Status {
+	case LifecycleEndOfLife:
+		return "End-of-life"
+	case LifecycleYanked:
+		return "Yanked"
+	default:
+		return "Deprecated"
+	}
+}
+
+// LifecycleNotice is shown on the pages of versions that have a lifecycle
+// status.
+type LifecycleNotice struct {
+	VersionLifecycle
+
+	// ReplacementHRef links to the documentation for the replacement
+	// version.
+	ReplacementHRef string `json:",omitempty"`
+}
+
+func lifecycleNotice(version *ModuleVersion, api string) *LifecycleNotice {
+	if version.Lifecycle == nil {
+		return nil
+	}
+
+	n := LifecycleNotice{
+		VersionLifecycle: *version.Lifecycle,
+	}
+
+	if n.Replacement != "" {
+		n.ReplacementHRef = fmt.Sprintf("/apis/%s/%s", api, n.Replacement)
+	}
+
+	return &n
+}
+
+// lifecycleRules returns the configured lifecycle rules followed by the rules
+// from the lifecycle file, read from the commit.
+func lifecycleRules(
+	module *Module, conf ModuleConfig, commit *object.Commit,
+) ([]LifecycleRule, error) {
+	rules := conf.Lifecycle
+
+	if conf.LifecycleFile == "" || commit == nil {
+		return rules, nil
+	}
+
+	tree, err := module.Tree(commit)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := tree.File(path.Clean(conf.LifecycleFile))
+	if errors.Is(err, object.ErrFileNotFound) {
+		return rules, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("open lifecycle file: %w", err)
+	}
+
+	data, err := f.Contents()
+	if err != nil {
+		return nil, fmt.Errorf("read lifecycle file: %w", err)
+	}
+
+	var fileRules []LifecycleRule
+
+	err = json.Unmarshal([]byte(data), &fileRules)
+	if err != nil {
+		return nil, fmt.Errorf("parse lifecycle file: %w", err)
+	}
+
+	return append(rules[:len(rules):len(rules)], fileRules...), nil
+}
+
+// applyLifecycle sets the lifecycle status of the versions from the first
+// rule that matches them.
+func applyLifecycle(versions []*ModuleVersion, rules []LifecycleRule) error {
+	type matcher struct {
+		rule       LifecycleRule
+		version    *semver.Version
+		constraint *semver.Constraints
+	}
+
+	matchers := make([]matcher, len(rules))
+
+	for i, r := range rules {
+		switch r.Status {
+		case LifecycleDeprecated, LifecycleEndOfLife, LifecycleYanked:
+		default:
+			return fmt.Errorf("unknown lifecycle status %q", r.Status)
+		}
+
+		matchers[i].rule = r
+
+		if v, err := semver.NewVersion(r.Versions); err == nil {
+			matchers[i].version = v
+
+			continue
+		}
+
+		c, err := semver.NewConstraint(r.Versions)
+		if err != nil {
+			return fmt.Errorf("invalid lifecycle versions %q: %w",
+				r.Versions, err)
+		}
+
+		matchers[i].constraint = c
+	}
+
+	for _, v := range versions {
+		for _, m := range matchers {
+			switch {
+			case m.version != nil && !m.version.Equal(v.Version):
+				continue
+			case m.constraint != nil && !matchesAnyConstraint(
+				v.Version, []*semver.Constraints{m.constraint}):
+				continue
+			}
+
+			v.Lifecycle = &VersionLifecycle{
+				Status:      m.rule.Status,
+				Reason:      m.rule.Reason,
+				Replacement: m.rule.Replacement,
+			}
+
+			break
+		}
+	}
+
+	return nil
+}
+
+// setReplacements points versions without an explicit replacement to the
+// latest version, and checks that explicit replacements exist.
+func setReplacements(module *Module) error {
+	for _, v := range module.Versions {
+		l := v.Lifecycle
+		if l == nil {
+			continue
+		}
+
+		if l.Replacement == "" {
+			if v != module.LatestVersion {
+				l.Replacement = module.LatestVersion.Tag
+			}
+
+			continue
+		}
+
+		documented := slices.ContainsFunc(module.Versions, func(mv *ModuleVersion) bool {
+			return mv.Tag == l.Replacement
+		})
+		if !documented {
+			return fmt.Errorf(
+				"replacement %q for %s is not a documented version",
+				l.Replacement, v.Tag)
+		}
+	}
+
+	return nil
+}
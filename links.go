@@ -0,0 +1,206 @@
+package elephantdocs
+
+import (
+	"cmp"
+	"fmt"
+	"html"
+	"html/template"
+	"net/url"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/go-git/go-git/v6/plumbing/transport"
+)
+
+// Source hosts with built in link templates.
+const (
+	HostGitHub = "github"
+	HostGitLab = "gitlab"
+	HostGitea  = "gitea"
+)
+
+var defaultLinkTemplates = map[string]LinkConfig{
+	HostGitHub: {
+		Commit:      "{repo}/commit/{hash}",
+		Tag:         "{repo}/tree/{tag}",
+		File:        "{repo}/blob/{ref}/{path}",
+		PullRequest: "{repo}/pull/{number}",
+	},
+	HostGitLab: {
+		Commit:      "{repo}/-/commit/{hash}",
+		Tag:         "{repo}/-/tags/{tag}",
+		File:        "{repo}/-/blob/{ref}/{path}",
+		PullRequest: "{repo}/-/merge_requests/{number}",
+	},
+	HostGitea: {
+		Commit:      "{repo}/commit/{hash}",
+		Tag:         "{repo}/src/tag/{tag}",
+		File:        "{repo}/src/{ref}/{path}",
+		PullRequest: "{repo}/pulls/{number}",
+	},
+}
+
+// SourceLinks creates links to the source repository of a module. Links are
+// empty when the repository URL is unknown.
+type SourceLinks struct {
+	repo string
+	dir  string
+	conf LinkConfig
+}
+
+// newSourceLinks creates the source links for a module, using the configured
+// link templates or the defaults for the host of the repository.
+func newSourceLinks(mod ModuleConfig, cloneURL string) (SourceLinks, error) {
+	var conf LinkConfig
+
+	if mod.Links != nil {
+		conf = *mod.Links
+	}
+
+	repo := conf.Repo
+	if repo == "" {
+		repo = repoWebURL(cloneURL)
+	}
+
+	// Fall back to the module path for local clones.
+	if repo == "" {
+		layout, err := newRepoLayout(ModuleConfig{
+			Name: mod.Name,
+			Dir:  mod.Dir,
+		})
+		if err != nil {
+			return SourceLinks{}, err
+		}
+
+		repo = repoWebURL(layout.CloneURL)
+	}
+
+	host := conf.Host
+	if host == "" {
+		host = detectHost(repo)
+	}
+
+	defaults, ok := defaultLinkTemplates[host]
+	if !ok {
+		return SourceLinks{}, fmt.Errorf("unknown source host %q", host)
+	}
+
+	conf.Commit = cmp.Or(conf.Commit, defaults.Commit)
+	conf.Tag = cmp.Or(conf.Tag, defaults.Tag)
+	conf.File = cmp.Or(conf.File, defaults.File)
+	conf.PullRequest = cmp.Or(conf.PullRequest, defaults.PullRequest)
+
+	return SourceLinks{
+		repo: strings.TrimSuffix(repo, "/"),
+		dir:  mod.Dir,
+		conf: conf,
+	}, nil
+}
+
+// repoWebURL returns the web URL of a repository from its clone URL, or an
+// empty string for local repositories.
+func repoWebURL(cloneURL string) string {
+	ep, err := transport.NewEndpoint(cloneURL)
+	if err != nil {
+		return ""
+	}
+
+	switch ep.Protocol {
+	case "http", "https", "ssh", "git":
+	default:
+		return ""
+	}
+
+	host := ep.Host
+	if (ep.Protocol == "http" || ep.Protocol == "https") && ep.Port != 0 {
+		host += ":" + strconv.Itoa(ep.Port)
+	}
+
+	u := url.URL{
+		Scheme: "https",
+		Host:   host,
+		Path:   "/" + strings.TrimSuffix(strings.Trim(ep.Path, "/"), ".git"),
+	}
+
+	if ep.Protocol == "http" {
+		u.Scheme = "http"
+	}
+
+	return u.String()
+}
+
+func detectHost(repo string) string {
+	u, err := url.Parse(repo)
+	if err != nil {
+		return HostGitHub
+	}
+
+	switch {
+	case strings.Contains(u.Hostname(), "gitlab"):
+		return HostGitLab
+	case strings.Contains(u.Hostname(), "gitea"),
+		strings.Contains(u.Hostname(), "codeberg"):
+		return HostGitea
+	default:
+		return HostGitHub
+	}
+}
+
+func (l SourceLinks) expand(tpl string, values ...string) string {
+	if l.repo == "" {
+		return ""
+	}
+
+	return strings.NewReplacer(
+		append([]string{"{repo}", l.repo}, values...)...,
+	).Replace(tpl)
+}
+
+// CommitURL returns the URL of a commit.
+func (l SourceLinks) CommitURL(hash string) string {
+	return l.expand(l.conf.Commit, "{hash}", hash)
+}
+
+// TagURL returns the URL of a tag.
+func (l SourceLinks) TagURL(tag string) string {
+	if tag == "" {
+		return ""
+	}
+
+	return l.expand(l.conf.Tag, "{tag}", tag)
+}
+
+// FileURL returns the URL of a file or directory in the module at a ref.
+func (l SourceLinks) FileURL(ref string, name string) string {
+	return l.expand(l.conf.File,
+		"{ref}", ref,
+		"{path}", path.Join(l.dir, name))
+}
+
+// PullRequestURL returns the URL of a pull request.
+func (l SourceLinks) PullRequestURL(number string) string {
+	return l.expand(l.conf.PullRequest, "{number}", number)
+}
+
+var pullRequestRefExp = regexp.MustCompile(`\(#(\d+)\)`)
+
+// LinkPullRequests escapes text and links pull request references like
+// "(#123)".
+func (l SourceLinks) LinkPullRequests(text string) template.HTML {
+	escaped := html.EscapeString(text)
+
+	if l.repo == "" {
+		return template.HTML(escaped)
+	}
+
+	linked := pullRequestRefExp.ReplaceAllStringFunc(escaped, func(ref string) string {
+		number := pullRequestRefExp.FindStringSubmatch(ref)[1]
+
+		return fmt.Sprintf(`(<a href="%s">#%s</a>)`,
+			html.EscapeString(l.PullRequestURL(number)), number)
+	})
+
+	return template.HTML(linked)
+}
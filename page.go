@@ -6,7 +6,10 @@ type Page struct {
 	Language   string
 	Menu       []MenuItem
 	Breadcrumb []MenuItem
-	Contents   any
+	// Feeds are Atom feeds for the page, in addition to the site-wide
+	// feed.
+	Feeds    []FeedLink
+	Contents any
 }
 
 type MenuItem struct {
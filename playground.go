@@ -0,0 +1,167 @@
+package elephantdocs
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/ttab/elephant-docs/internal"
+	"github.com/ttab/newsdoc"
+)
+
+// playgroundPackage is the WebAssembly module of the schema validation
+// playground.
+const playgroundPackage = "github.com/ttab/elephant-docs/cmd/schema-playground"
+
+// SchemaPlaygroundPage is the data for the schema playground template.
+type SchemaPlaygroundPage struct {
+	Version  string
+	Versions []SchemaVersionLink
+}
+
+// errPlaygroundUnavailable is returned when the WebAssembly module of the
+// playground can't be built.
+var errPlaygroundUnavailable = errors.New(
+	"the schema playground is built from the elephant-docs module source " +
+		"and requires the Go toolchain")
+
+// checkPlayground checks that the WebAssembly module of the playground can be
+// built, which requires the go command and the source of the elephant-docs
+// module, f.ex. when it runs from a checkout of the repository or with
+// "go run".
+func checkPlayground() error {
+	_, err := exec.LookPath("go")
+	if err != nil {
+		return fmt.Errorf("%w: %w", errPlaygroundUnavailable, err)
+	}
+
+	list := exec.Command("go", "list", playgroundPackage)
+
+	list.Env = append(os.Environ(), "GOOS=js", "GOARCH=wasm")
+
+	out, err := list.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%w: %s", errPlaygroundUnavailable,
+			strings.TrimSpace(string(out)))
+	}
+
+	return nil
+}
+
+// buildPlayground compiles the WebAssembly module of the playground and
+// copies the Go WebAssembly support script to the assets directory.
+func buildPlayground(outDir string) error {
+	dir := filepath.Join(outDir, "assets", "wasm")
+
+	err := os.MkdirAll(dir, 0o700)
+	if err != nil {
+		return fmt.Errorf("create directory: %w", err)
+	}
+
+	build := exec.Command("go", "build",
+		"-trimpath", "-ldflags=-s -w",
+		"-o", filepath.Join(dir, "schema-playground.wasm"),
+		playgroundPackage)
+
+	build.Env = append(os.Environ(), "GOOS=js", "GOARCH=wasm")
+
+	out, err := build.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("build WebAssembly module: %w: %s",
+			err, strings.TrimSpace(string(out)))
+	}
+
+	goroot, err := exec.Command("go", "env", "GOROOT").Output()
+	if err != nil {
+		return fmt.Errorf("get GOROOT: %w", err)
+	}
+
+	root := strings.TrimSpace(string(goroot))
+
+	// The support script was moved from misc/wasm to lib/wasm in Go 1.24.
+	var script []byte
+
+	for _, p := range []string{"lib/wasm", "misc/wasm"} {
+		script, err = os.ReadFile(filepath.Join(root, p, "wasm_exec.js"))
+		if err == nil {
+			break
+		}
+	}
+
+	if err != nil {
+		return fmt.Errorf("read wasm_exec.js: %w", err)
+	}
+
+	err = os.WriteFile(filepath.Join(dir, "wasm_exec.js"), script, 0o644)
+	if err != nil {
+		return fmt.Errorf("write wasm_exec.js: %w", err)
+	}
+
+	return nil
+}
+
+// renderSchemaPlayground renders the playground page of a schema version with
+// its constraint sets, link index and examples.
+func renderSchemaPlayground(
+	outDir string, tpl *template.Template, menu []MenuItem,
+	current *SchemaVersion, root string, versions []SchemaVersionLink,
+) error {
+	dir := filepath.Join(outDir, "playground")
+
+	err := renderPage(dir, tpl, "schema_playground.html", Page{
+		Title: "Schema Playground",
+		Menu:  markActive(menu, root+"/playground"),
+		Contents: SchemaPlaygroundPage{
+			Version:  current.Tag,
+			Versions: versions,
+		},
+		Breadcrumb: []MenuItem{
+			{Title: "Home", HRef: "/"},
+			{Title: "Schemas", HRef: root},
+			{Title: "Playground"},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	err = internal.MarshalFile(
+		filepath.Join(dir, "constraints.json"), current.constraints)
+	if err != nil {
+		return fmt.Errorf("write constraint sets: %w", err)
+	}
+
+	err = internal.MarshalFile(filepath.Join(dir, "links.json"),
+		newLinkIndex(current.Doc, root))
+	if err != nil {
+		return fmt.Errorf("write link index: %w", err)
+	}
+
+	err = internal.MarshalFile(filepath.Join(dir, "examples.json"),
+		playgroundExamples(current.Doc))
+	if err != nil {
+		return fmt.Errorf("write examples: %w", err)
+	}
+
+	return nil
+}
+
+// playgroundExamples collects the minimal examples of the declared document
+// types.
+func playgroundExamples(doc *SchemaDoc) map[string]*newsdoc.Document {
+	examples := make(map[string]*newsdoc.Document)
+
+	for _, d := range doc.Documents {
+		if d.DeclaredIn == "" || d.Examples == nil {
+			continue
+		}
+
+		examples[d.Type] = &d.Examples.Minimal
+	}
+
+	return examples
+}
@@ -16,7 +16,10 @@ type ProtoHandle struct {
 	API     string
 	Module  string
 	Version string
-	Proto   ProtoDeclarations
+	// Commit is set when the files have been read from an untagged
+	// commit.
+	Commit string
+	Proto  ProtoDeclarations
 }
 
 type ProtoDeclarations struct {
@@ -48,6 +51,9 @@ type ProtoMessage struct {
 	Name    string
 	Comment string
 	Fields  []ProtoField
+	// Messages and Enums are the types that are declared in the message.
+	Messages []ProtoMessage `json:",omitempty"`
+	Enums    []ProtoEnum    `json:",omitempty"`
 }
 
 type ProtoEnum struct {
@@ -89,16 +95,13 @@ type MessageRef struct {
 }
 
 func parseProtoFiles(
-	version *ModuleVersion, api string,
+	tree *object.Tree, api string,
 ) ([]ProtoDeclarations, error) {
-	tree, err := version.Commit.Tree()
-	if err != nil {
-		return nil, fmt.Errorf("get tag tree: %w", err)
-	}
-
 	apiDir, err := tree.Tree(api)
 	if errors.Is(err, object.ErrDirectoryNotFound) {
 		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("get API directory: %w", err)
 	}
 
 	var protos []ProtoDeclarations
@@ -159,27 +162,42 @@ func createProtoDeclaration(pf *parser.Proto) ProtoDeclarations {
 
 			d.Services = append(d.Services, s)
 		case *parser.Message:
-			m := ProtoMessage{
-				Doc:    collectComments(o.Comments),
-				Name:   o.MessageName,
-				Fields: collectFields(o),
-			}
-
-			d.Messages = append(d.Messages, m)
+			d.Messages = append(d.Messages, createProtoMessage(o))
 		case *parser.Enum:
-			e := ProtoEnum{
-				Doc:    collectComments(o.Comments),
-				Name:   o.EnumName,
-				Values: collectEnumValues(o),
-			}
-
-			d.Enums = append(d.Enums, e)
+			d.Enums = append(d.Enums, createProtoEnum(o))
 		}
 	}
 
 	return d
 }
 
+func createProtoMessage(msg *parser.Message) ProtoMessage {
+	m := ProtoMessage{
+		Doc:    collectComments(msg.Comments),
+		Name:   msg.MessageName,
+		Fields: collectFields(msg),
+	}
+
+	for _, v := range msg.MessageBody {
+		switch o := v.(type) {
+		case *parser.Message:
+			m.Messages = append(m.Messages, createProtoMessage(o))
+		case *parser.Enum:
+			m.Enums = append(m.Enums, createProtoEnum(o))
+		}
+	}
+
+	return m
+}
+
+func createProtoEnum(enum *parser.Enum) ProtoEnum {
+	return ProtoEnum{
+		Doc:    collectComments(enum.Comments),
+		Name:   enum.EnumName,
+		Values: collectEnumValues(enum),
+	}
+}
+
 var scalars = map[string]bool{
 	"double":   true,
 	"float":    true,
@@ -0,0 +1,454 @@
+package elephantdocs
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/go-git/go-git/v6/plumbing"
+	"github.com/go-git/go-git/v6/plumbing/object"
+)
+
+// ProtoChange is a change to the declarations of an API.
+type ProtoChange struct {
+	// Change is "added", "removed" or "changed".
+	Change string
+	// Kind is the kind of declaration, like "field" or "method".
+	Kind string
+	// Name is the qualified name of the declaration, like
+	// "Document.language".
+	Name string
+	// Detail describes changed declarations, like "string to int32".
+	Detail string `json:",omitempty"`
+}
+
+func (c ProtoChange) String() string {
+	s := fmt.Sprintf("%s %s `%s`", c.Change, c.Kind, c.Name)
+
+	if c.Detail != "" {
+		s += ": " + c.Detail
+	}
+
+	return s
+}
+
+// protoChangeSummarizer summarises the changes that commits made to the proto
+// declarations of an API.
+type protoChangeSummarizer struct {
+	module *Module
+	api    string
+	// cache holds the parsed declarations by the hash of the API
+	// directory tree.
+	cache map[plumbing.Hash][]ProtoDeclarations
+}
+
+func newProtoChangeSummarizer(module *Module, api string) *protoChangeSummarizer {
+	return &protoChangeSummarizer{
+		module: module,
+		api:    api,
+		cache:  make(map[plumbing.Hash][]ProtoDeclarations),
+	}
+}
+
+// Summarize diffs the declarations of the API before and after the commit.
+// Commits that can't be parsed get no summary.
+func (s *protoChangeSummarizer) Summarize(commit *object.Commit) []ProtoChange {
+	after, err := s.declarations(commit)
+	if err != nil {
+		slog.Warn("failed to read API declarations",
+			"api", s.api,
+			"commit", commit.Hash.String(),
+			"err", err)
+
+		return nil
+	}
+
+	var before []ProtoDeclarations
+
+	if commit.NumParents() > 0 {
+		parent, err := commit.Parent(0)
+		if err != nil {
+			slog.Warn("failed to read parent commit",
+				"commit", commit.Hash.String(),
+				"err", err)
+
+			return nil
+		}
+
+		before, err = s.declarations(parent)
+		if err != nil {
+			slog.Warn("failed to read API declarations",
+				"api", s.api,
+				"commit", parent.Hash.String(),
+				"err", err)
+
+			return nil
+		}
+	}
+
+	return diffProtoDeclarations(before, after)
+}
+
+func (s *protoChangeSummarizer) declarations(
+	commit *object.Commit,
+) ([]ProtoDeclarations, error) {
+	// The module and API directories don't exist before they were
+	// created.
+	tree, err := s.module.Tree(commit)
+	if errors.Is(err, object.ErrDirectoryNotFound) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	apiTree, err := tree.Tree(s.api)
+	if errors.Is(err, object.ErrDirectoryNotFound) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("get API directory: %w", err)
+	}
+
+	if decls, ok := s.cache[apiTree.Hash]; ok {
+		return decls, nil
+	}
+
+	decls, err := parseProtoFiles(tree, s.api)
+	if err != nil {
+		return nil, err
+	}
+
+	s.cache[apiTree.Hash] = decls
+
+	return decls, nil
+}
+
+// diffProtoDeclarations lists the services, methods, messages, fields, enums
+// and enum values that were added, removed or changed. Nested messages and
+// enums are compared by their qualified names.
+func diffProtoDeclarations(before, after []ProtoDeclarations) []ProtoChange {
+	var changes []ProtoChange
+
+	oldServices := make(map[string]ProtoService)
+	oldMessages := make(map[string]ProtoMessage)
+	oldEnums := make(map[string]ProtoEnum)
+
+	for _, d := range before {
+		for _, s := range d.Services {
+			oldServices[s.Name] = s
+		}
+
+		messages, enums := flattenProtoTypes(d)
+
+		for _, m := range messages {
+			oldMessages[m.Name] = m
+		}
+
+		for _, e := range enums {
+			oldEnums[e.Name] = e
+		}
+	}
+
+	newServices := make(map[string]bool)
+	newMessages := make(map[string]bool)
+	newEnums := make(map[string]bool)
+
+	// Types that are nested in an added or removed message are implied
+	// by it and aren't listed separately.
+	existedBefore := func(name string) bool {
+		parent := parentMessage(name)
+		_, ok := oldMessages[parent]
+
+		return parent == "" || ok
+	}
+
+	existsAfter := func(name string) bool {
+		parent := parentMessage(name)
+
+		return parent == "" || newMessages[parent]
+	}
+
+	for _, d := range after {
+		for _, s := range d.Services {
+			newServices[s.Name] = true
+
+			old, ok := oldServices[s.Name]
+			if !ok {
+				changes = append(changes, ProtoChange{
+					Change: "added", Kind: "service", Name: s.Name,
+				})
+
+				continue
+			}
+
+			changes = append(changes, diffMethods(s.Name, old.Methods, s.Methods)...)
+		}
+
+		messages, enums := flattenProtoTypes(d)
+
+		for _, m := range messages {
+			newMessages[m.Name] = true
+
+			old, ok := oldMessages[m.Name]
+			if !ok {
+				if existedBefore(m.Name) {
+					changes = append(changes, ProtoChange{
+						Change: "added", Kind: "message", Name: m.Name,
+					})
+				}
+
+				continue
+			}
+
+			changes = append(changes, diffFields(m.Name, old.Fields, m.Fields)...)
+		}
+
+		for _, e := range enums {
+			newEnums[e.Name] = true
+
+			old, ok := oldEnums[e.Name]
+			if !ok {
+				if existedBefore(e.Name) {
+					changes = append(changes, ProtoChange{
+						Change: "added", Kind: "enum", Name: e.Name,
+					})
+				}
+
+				continue
+			}
+
+			changes = append(changes, diffEnumValues(e.Name, old.Values, e.Values)...)
+		}
+	}
+
+	for _, d := range before {
+		for _, s := range d.Services {
+			if !newServices[s.Name] {
+				changes = append(changes, ProtoChange{
+					Change: "removed", Kind: "service", Name: s.Name,
+				})
+			}
+		}
+
+		messages, enums := flattenProtoTypes(d)
+
+		for _, m := range messages {
+			if !newMessages[m.Name] && existsAfter(m.Name) {
+				changes = append(changes, ProtoChange{
+					Change: "removed", Kind: "message", Name: m.Name,
+				})
+			}
+		}
+
+		for _, e := range enums {
+			if !newEnums[e.Name] && existsAfter(e.Name) {
+				changes = append(changes, ProtoChange{
+					Change: "removed", Kind: "enum", Name: e.Name,
+				})
+			}
+		}
+	}
+
+	return changes
+}
+
+// flattenProtoTypes lists the messages and enums of a file, including the
+// ones that are nested in messages, which get names qualified by their
+// parents, like "Document.Meta".
+func flattenProtoTypes(d ProtoDeclarations) ([]ProtoMessage, []ProtoEnum) {
+	var (
+		messages []ProtoMessage
+		enums    []ProtoEnum
+	)
+
+	var walk func(prefix string, msgs []ProtoMessage, es []ProtoEnum)
+
+	walk = func(prefix string, msgs []ProtoMessage, es []ProtoEnum) {
+		for _, e := range es {
+			e.Name = prefix + e.Name
+			enums = append(enums, e)
+		}
+
+		for _, m := range msgs {
+			m.Name = prefix + m.Name
+			messages = append(messages, m)
+
+			walk(m.Name+".", m.Messages, m.Enums)
+		}
+	}
+
+	walk("", d.Messages, d.Enums)
+
+	return messages, enums
+}
+
+// parentMessage returns the name of the message that a nested type is declared
+// in, or an empty string for top level types.
+func parentMessage(name string) string {
+	i := strings.LastIndex(name, ".")
+	if i == -1 {
+		return ""
+	}
+
+	return name[:i]
+}
+
+func diffMethods(service string, before, after []ProtoMethod) []ProtoChange {
+	var changes []ProtoChange
+
+	old := make(map[string]ProtoMethod, len(before))
+
+	for _, m := range before {
+		old[m.Name] = m
+	}
+
+	seen := make(map[string]bool, len(after))
+
+	for _, m := range after {
+		seen[m.Name] = true
+
+		name := service + "." + m.Name
+
+		o, ok := old[m.Name]
+		if !ok {
+			changes = append(changes, ProtoChange{
+				Change: "added", Kind: "method", Name: name,
+			})
+
+			continue
+		}
+
+		if o.Request != m.Request {
+			changes = append(changes, ProtoChange{
+				Change: "changed", Kind: "method", Name: name,
+				Detail: fmt.Sprintf("request %s to %s",
+					messageRefString(o.Request), messageRefString(m.Request)),
+			})
+		}
+
+		if o.Response != m.Response {
+			changes = append(changes, ProtoChange{
+				Change: "changed", Kind: "method", Name: name,
+				Detail: fmt.Sprintf("response %s to %s",
+					messageRefString(o.Response), messageRefString(m.Response)),
+			})
+		}
+	}
+
+	for _, m := range before {
+		if !seen[m.Name] {
+			changes = append(changes, ProtoChange{
+				Change: "removed", Kind: "method", Name: service + "." + m.Name,
+			})
+		}
+	}
+
+	return changes
+}
+
+func diffFields(message string, before, after []ProtoField) []ProtoChange {
+	var changes []ProtoChange
+
+	old := make(map[string]ProtoField, len(before))
+
+	for _, f := range before {
+		old[f.Name] = f
+	}
+
+	seen := make(map[string]bool, len(after))
+
+	for _, f := range after {
+		seen[f.Name] = true
+
+		name := message + "." + f.Name
+
+		o, ok := old[f.Name]
+		if !ok {
+			changes = append(changes, ProtoChange{
+				Change: "added", Kind: "field", Name: name,
+			})
+
+			continue
+		}
+
+		oldType, newType := fieldTypeString(o), fieldTypeString(f)
+		if oldType != newType {
+			changes = append(changes, ProtoChange{
+				Change: "changed", Kind: "field", Name: name,
+				Detail: fmt.Sprintf("%s to %s", oldType, newType),
+			})
+		}
+	}
+
+	for _, f := range before {
+		if !seen[f.Name] {
+			changes = append(changes, ProtoChange{
+				Change: "removed", Kind: "field", Name: message + "." + f.Name,
+			})
+		}
+	}
+
+	return changes
+}
+
+func diffEnumValues(enum string, before, after []ProtoEnumValue) []ProtoChange {
+	var changes []ProtoChange
+
+	old := make(map[string]ProtoEnumValue, len(before))
+
+	for _, v := range before {
+		old[v.Name] = v
+	}
+
+	seen := make(map[string]bool, len(after))
+
+	for _, v := range after {
+		seen[v.Name] = true
+
+		if _, ok := old[v.Name]; !ok {
+			changes = append(changes, ProtoChange{
+				Change: "added", Kind: "enum value", Name: enum + "." + v.Name,
+			})
+		}
+	}
+
+	for _, v := range before {
+		if !seen[v.Name] {
+			changes = append(changes, ProtoChange{
+				Change: "removed", Kind: "enum value", Name: enum + "." + v.Name,
+			})
+		}
+	}
+
+	return changes
+}
+
+func messageRefString(ref MessageRef) string {
+	if ref.Package == "" {
+		return ref.Message
+	}
+
+	return ref.Package + "." + ref.Message
+}
+
+func fieldTypeString(f ProtoField) string {
+	if len(f.OneOf) > 0 {
+		return "oneof"
+	}
+
+	t := f.Type
+	name := t.Scalar
+
+	if t.Message != nil {
+		name = messageRefString(*t.Message)
+	}
+
+	switch {
+	case t.MappedBy != "":
+		return fmt.Sprintf("map<%s, %s>", t.MappedBy, name)
+	case t.Repeated:
+		return "repeated " + name
+	default:
+		return name
+	}
+}
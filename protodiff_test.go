@@ -0,0 +1,116 @@
+package elephantdocs
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	protoparser "github.com/yoheimuta/go-protoparser/v4"
+)
+
+func mustParseProto(t *testing.T, source string) []ProtoDeclarations {
+	t.Helper()
+
+	pf, err := protoparser.Parse(strings.NewReader(source))
+	if err != nil {
+		t.Fatalf("parse proto: %v", err)
+	}
+
+	return []ProtoDeclarations{createProtoDeclaration(pf)}
+}
+
+func TestDiffProtoDeclarations(t *testing.T) {
+	before := mustParseProto(t, `syntax = "proto3";
+
+package example;
+
+service Documents {
+  rpc Get(GetRequest) returns (GetResponse);
+}
+
+message GetRequest {
+  string uuid = 1;
+}
+
+message GetResponse {
+  message Meta {
+    string title = 1;
+
+    enum Status {
+      DRAFT = 0;
+    }
+  }
+
+  Meta meta = 1;
+}
+
+message Obsolete {
+  message Part {
+    string name = 1;
+  }
+}
+`)
+
+	after := mustParseProto(t, `syntax = "proto3";
+
+package example;
+
+service Documents {
+  rpc Get(GetDocumentRequest) returns (GetDocumentResponse);
+}
+
+message GetDocumentRequest {
+  string uuid = 1;
+}
+
+message GetDocumentResponse {}
+
+message GetResponse {
+  message Meta {
+    int32 title = 1;
+    string language = 2;
+
+    enum Status {
+      DRAFT = 0;
+      DONE = 1;
+    }
+  }
+
+  message Extra {
+    string note = 1;
+  }
+
+  Meta meta = 1;
+}
+
+message GetRequest {
+  string uuid = 1;
+}
+`)
+
+	var got []string
+
+	for _, c := range diffProtoDeclarations(before, after) {
+		got = append(got, c.String())
+	}
+
+	want := []string{
+		"changed method `Documents.Get`: request GetRequest to GetDocumentRequest",
+		"changed method `Documents.Get`: response GetResponse to GetDocumentResponse",
+		"added message `GetDocumentRequest`",
+		"added message `GetDocumentResponse`",
+		"changed field `GetResponse.Meta.title`: string to int32",
+		"added field `GetResponse.Meta.language`",
+		"added message `GetResponse.Extra`",
+		"added enum value `GetResponse.Meta.Status.DONE`",
+		"removed message `Obsolete`",
+	}
+
+	slices.Sort(got)
+	slices.Sort(want)
+
+	if !slices.Equal(got, want) {
+		t.Errorf("got changes:\n%s\nwant:\n%s",
+			strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
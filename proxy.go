@@ -0,0 +1,508 @@
+package elephantdocs
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/go-git/go-git/v6"
+	"github.com/go-git/go-git/v6/plumbing"
+	"github.com/go-git/go-git/v6/plumbing/filemode"
+	"github.com/go-git/go-git/v6/plumbing/object"
+	"github.com/go-git/go-git/v6/storage/memory"
+	"golang.org/x/mod/module"
+)
+
+const defaultGoProxy = "https://proxy.golang.org"
+
+// moduleProxy reads module versions using the GOPROXY protocol, see
+// https://go.dev/ref/mod#goproxy-protocol.
+type moduleProxy struct {
+	base    *url.URL
+	path    string
+	escaped string
+	auth    interface{ SetAuth(r *http.Request) }
+	client  *http.Client
+}
+
+// proxyVersionInfo is the response of the "/@v/<version>.info" endpoint.
+type proxyVersionInfo struct {
+	Version string
+	Time    time.Time
+}
+
+// newProxyModule creates a module from the versions published through a Go
+// module proxy. The contents of each version are stored as a synthetic commit
+// in an in-memory repository so that they can be read like the tags of a
+// cloned module. The commits have no parents, so there is no history to build
+// changelogs from. Only the versions that are selected for documentation are
+// downloaded up front.
+func newProxyModule(mod ModuleConfig) (*Module, error) {
+	if mod.Unreleased != nil {
+		return nil, errors.New("the unreleased channel requires a git source")
+	}
+
+	proxy, err := newModuleProxy(mod)
+	if err != nil {
+		return nil, err
+	}
+
+	layout, err := newRepoLayout(mod)
+	if err != nil {
+		return nil, err
+	}
+
+	links, err := newSourceLinks(mod, layout.CloneURL)
+	if err != nil {
+		return nil, fmt.Errorf("configure source links: %w", err)
+	}
+
+	repo, err := git.Init(memory.NewStorage())
+	if err != nil {
+		return nil, fmt.Errorf("create repository: %w", err)
+	}
+
+	module := Module{
+		Title:         mod.Title,
+		Name:          mod.Name,
+		Source:        SourceProxy,
+		Repo:          repo,
+		VersionLookup: make(map[string]*ModuleVersion),
+		APIs:          mod.APIs,
+		Include:       mod.Include,
+		Changelog:     mod.Changelog,
+		Links:         links,
+	}
+
+	list, err := proxy.fetch("list")
+	if err != nil {
+		return nil, fmt.Errorf("list versions: %w", err)
+	}
+
+	for line := range strings.Lines(string(list)) {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		name := fields[0]
+
+		version, err := semver.NewVersion(name)
+		if err != nil {
+			continue
+		}
+
+		mv := ModuleVersion{
+			Tag:          name,
+			Version:      version,
+			IsPrerelease: version.Prerelease() != "",
+		}
+
+		module.Versions = append(module.Versions, &mv)
+		module.VersionLookup[mv.Tag] = &mv
+	}
+
+	// Versions that aren't documented are only read if a dependency
+	// points to them, which can happen while the pages are rendered. They
+	// get a repository of their own so that the shared one isn't written
+	// to concurrently.
+	var mu sync.Mutex
+
+	module.loadCommit = func(v *ModuleVersion) error {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if v.Commit != nil {
+			return nil
+		}
+
+		lazyRepo, err := git.Init(memory.NewStorage())
+		if err != nil {
+			return fmt.Errorf("create repository: %w", err)
+		}
+
+		return proxy.loadVersion(lazyRepo, v)
+	}
+
+	// The lifecycle file is read from the newest version, before the
+	// versions are selected.
+	var head *object.Commit
+
+	if mod.LifecycleFile != "" && len(module.Versions) > 0 {
+		sortVersionsDesc(module.Versions)
+
+		newest := module.Versions[0]
+
+		err := proxy.loadVersion(repo, newest)
+		if err != nil {
+			return nil, err
+		}
+
+		head = newest.Commit
+	}
+
+	err = selectVersions(&module, mod, head)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only download the versions that are documented.
+	for _, v := range module.Versions {
+		if v.Commit != nil {
+			continue
+		}
+
+		err := proxy.loadVersion(repo, v)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &module, nil
+}
+
+// loadVersion reads the commit of a version and sets its tag date.
+func (p *moduleProxy) loadVersion(repo *git.Repository, v *ModuleVersion) error {
+	commit, err := p.versionCommit(repo, v.Tag)
+	if err != nil {
+		return fmt.Errorf("read version %s: %w", v.Tag, err)
+	}
+
+	v.Commit = commit
+	v.Tagged = commit.Committer.When
+
+	return nil
+}
+
+func newModuleProxy(mod ModuleConfig) (*moduleProxy, error) {
+	proxyURL := mod.Proxy
+	if proxyURL == "" {
+		proxyURL = goProxyFromEnv()
+	}
+
+	base, err := url.Parse(proxyURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy URL: %w", err)
+	}
+
+	escaped, err := module.EscapePath(mod.Name)
+	if err != nil {
+		return nil, fmt.Errorf("invalid module path: %w", err)
+	}
+
+	p := moduleProxy{
+		base:    base,
+		path:    mod.Name,
+		escaped: escaped,
+		client: &http.Client{
+			Timeout: 5 * time.Minute,
+		},
+	}
+
+	if base.Scheme == "http" || base.Scheme == "https" {
+		auth, err := cloneAuth(proxyURL, mod.Auth)
+		if err != nil {
+			return nil, fmt.Errorf("configure authentication: %w", err)
+		}
+
+		if a, ok := auth.(interface{ SetAuth(r *http.Request) }); ok {
+			p.auth = a
+		}
+	}
+
+	return &p, nil
+}
+
+// goProxyFromEnv returns the first usable proxy from the GOPROXY environment
+// variable, falling back to the public Go module proxy.
+func goProxyFromEnv() string {
+	list := os.Getenv("GOPROXY")
+
+	for entry := range strings.FieldsFuncSeq(list, func(r rune) bool {
+		return r == ',' || r == '|'
+	}) {
+		if entry == "direct" || entry == "off" {
+			continue
+		}
+
+		return entry
+	}
+
+	return defaultGoProxy
+}
+
+// fetch reads a file from the "/@v/" directory of the module.
+func (p *moduleProxy) fetch(name string) (_ []byte, outErr error) {
+	if p.base.Scheme == "file" {
+		data, err := os.ReadFile(filepath.Join(
+			filepath.FromSlash(p.base.Path),
+			filepath.FromSlash(p.escaped), "@v", name))
+		if err != nil {
+			return nil, fmt.Errorf("read %q: %w", name, err)
+		}
+
+		return data, nil
+	}
+
+	u := p.base.JoinPath(p.escaped, "@v", name)
+
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+
+	if p.auth != nil {
+		p.auth.SetAuth(req)
+	}
+
+	res, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetch %q: %w", name, err)
+	}
+
+	defer func() {
+		err := res.Body.Close()
+		if err != nil {
+			outErr = errors.Join(outErr, fmt.Errorf(
+				"close response body: %w", err))
+		}
+	}()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetch %q: server responded with %s",
+			name, res.Status)
+	}
+
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read %q: %w", name, err)
+	}
+
+	return data, nil
+}
+
+// versionCommit downloads the module zip and go.mod file for a version and
+// stores them as a commit in the repository.
+func (p *moduleProxy) versionCommit(
+	repo *git.Repository, version string,
+) (*object.Commit, error) {
+	escVersion, err := module.EscapeVersion(version)
+	if err != nil {
+		return nil, fmt.Errorf("invalid version: %w", err)
+	}
+
+	infoData, err := p.fetch(escVersion + ".info")
+	if err != nil {
+		return nil, fmt.Errorf("get version info: %w", err)
+	}
+
+	var info proxyVersionInfo
+
+	err = json.Unmarshal(infoData, &info)
+	if err != nil {
+		return nil, fmt.Errorf("parse version info: %w", err)
+	}
+
+	modData, err := p.fetch(escVersion + ".mod")
+	if err != nil {
+		return nil, fmt.Errorf("get go.mod: %w", err)
+	}
+
+	zipData, err := p.fetch(escVersion + ".zip")
+	if err != nil {
+		return nil, fmt.Errorf("get module zip: %w", err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(zipData), int64(len(zipData)))
+	if err != nil {
+		return nil, fmt.Errorf("open module zip: %w", err)
+	}
+
+	root := newTreeNode()
+
+	// All files in a module zip are prefixed with "<module>@<version>/",
+	// using the unescaped module path.
+	prefix := p.path + "@" + version + "/"
+
+	for _, f := range zr.File {
+		name, ok := strings.CutPrefix(f.Name, prefix)
+		if !ok || strings.HasSuffix(name, "/") {
+			continue
+		}
+
+		data, err := readZipFile(f)
+		if err != nil {
+			return nil, err
+		}
+
+		root.add(name, data)
+	}
+
+	// The go.mod from the proxy is authoritative, and is synthesised by
+	// the proxy for modules that lack one.
+	root.add("go.mod", modData)
+
+	store := repo.Storer
+
+	treeHash, err := root.store(store)
+	if err != nil {
+		return nil, fmt.Errorf("store module tree: %w", err)
+	}
+
+	sig := object.Signature{
+		Name: "Go module proxy",
+		When: info.Time,
+	}
+
+	commit := object.Commit{
+		Author:    sig,
+		Committer: sig,
+		Message:   fmt.Sprintf("%s@%s", p.path, version),
+		TreeHash:  treeHash,
+	}
+
+	obj := store.NewEncodedObject()
+
+	err = commit.Encode(obj)
+	if err != nil {
+		return nil, fmt.Errorf("encode commit: %w", err)
+	}
+
+	hash, err := store.SetEncodedObject(obj)
+	if err != nil {
+		return nil, fmt.Errorf("store commit: %w", err)
+	}
+
+	return object.GetCommit(store, hash)
+}
+
+func readZipFile(f *zip.File) (_ []byte, outErr error) {
+	r, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("open %q in zip: %w", f.Name, err)
+	}
+
+	defer func() {
+		err := r.Close()
+		if err != nil {
+			outErr = errors.Join(outErr, fmt.Errorf(
+				"close %q in zip: %w", f.Name, err))
+		}
+	}()
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("read %q in zip: %w", f.Name, err)
+	}
+
+	return data, nil
+}
+
+// treeNode is a directory used to build git trees from a flat list of files.
+type treeNode struct {
+	files map[string][]byte
+	dirs  map[string]*treeNode
+}
+
+func newTreeNode() *treeNode {
+	return &treeNode{
+		files: make(map[string][]byte),
+		dirs:  make(map[string]*treeNode),
+	}
+}
+
+func (n *treeNode) add(name string, data []byte) {
+	dir, rest, nested := strings.Cut(name, "/")
+	if !nested {
+		n.files[name] = data
+
+		return
+	}
+
+	child, ok := n.dirs[dir]
+	if !ok {
+		child = newTreeNode()
+		n.dirs[dir] = child
+	}
+
+	child.add(rest, data)
+}
+
+type objectStorer interface {
+	NewEncodedObject() plumbing.EncodedObject
+	SetEncodedObject(o plumbing.EncodedObject) (plumbing.Hash, error)
+}
+
+// store writes the blobs and trees of the node and returns the hash of its
+// tree.
+func (n *treeNode) store(s objectStorer) (plumbing.Hash, error) {
+	var tree object.Tree
+
+	for name, data := range n.files {
+		obj := s.NewEncodedObject()
+		obj.SetType(plumbing.BlobObject)
+		obj.SetSize(int64(len(data)))
+
+		w, err := obj.Writer()
+		if err != nil {
+			return plumbing.ZeroHash, fmt.Errorf("open blob writer: %w", err)
+		}
+
+		_, err = w.Write(data)
+		if err != nil {
+			return plumbing.ZeroHash, fmt.Errorf("write blob: %w", err)
+		}
+
+		err = w.Close()
+		if err != nil {
+			return plumbing.ZeroHash, fmt.Errorf("close blob writer: %w", err)
+		}
+
+		hash, err := s.SetEncodedObject(obj)
+		if err != nil {
+			return plumbing.ZeroHash, fmt.Errorf("store blob %q: %w", name, err)
+		}
+
+		tree.Entries = append(tree.Entries, object.TreeEntry{
+			Name: name,
+			Mode: filemode.Regular,
+			Hash: hash,
+		})
+	}
+
+	for name, child := range n.dirs {
+		hash, err := child.store(s)
+		if err != nil {
+			return plumbing.ZeroHash, fmt.Errorf("%s: %w", name, err)
+		}
+
+		tree.Entries = append(tree.Entries, object.TreeEntry{
+			Name: name,
+			Mode: filemode.Dir,
+			Hash: hash,
+		})
+	}
+
+	sort.Sort(object.TreeEntrySorter(tree.Entries))
+
+	obj := s.NewEncodedObject()
+
+	err := tree.Encode(obj)
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("encode tree: %w", err)
+	}
+
+	return s.SetEncodedObject(obj)
+}
@@ -0,0 +1,111 @@
+package elephantdocs
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeProxyVersions lays out a file based module proxy. Only the versions in
+// withZip get a module zip.
+func writeProxyVersions(
+	t *testing.T, dir string, path string, versions []string, withZip []string,
+) {
+	t.Helper()
+
+	vDir := filepath.Join(dir, path, "@v")
+
+	err := os.MkdirAll(vDir, 0o700)
+	if err != nil {
+		t.Fatalf("create proxy directory: %v", err)
+	}
+
+	var list bytes.Buffer
+
+	write := func(name string, data []byte) {
+		err := os.WriteFile(filepath.Join(vDir, name), data, 0o600)
+		if err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+
+	for _, v := range versions {
+		list.WriteString(v + "\n")
+
+		write(v+".info", []byte(`{"Version":"`+v+`","Time":"2025-01-01T00:00:00Z"}`))
+		write(v+".mod", []byte("module "+path+"\n"))
+	}
+
+	write("list", list.Bytes())
+
+	for _, v := range withZip {
+		var buf bytes.Buffer
+
+		zw := zip.NewWriter(&buf)
+
+		w, err := zw.Create(path + "@" + v + "/README.md")
+		if err != nil {
+			t.Fatalf("create zip entry: %v", err)
+		}
+
+		_, err = w.Write([]byte("# " + v))
+		if err != nil {
+			t.Fatalf("write zip entry: %v", err)
+		}
+
+		err = zw.Close()
+		if err != nil {
+			t.Fatalf("close zip: %v", err)
+		}
+
+		write(v+".zip", buf.Bytes())
+	}
+}
+
+func TestNewProxyModuleOnlyDownloadsSelectedVersions(t *testing.T) {
+	dir := t.TempDir()
+
+	// The zip of v1.0.0 is missing, so reading it fails.
+	writeProxyVersions(t, dir, "example.com/api",
+		[]string{"v1.0.0", "v1.1.0", "v1.2.0"},
+		[]string{"v1.1.0", "v1.2.0"})
+
+	mod, err := newProxyModule(ModuleConfig{
+		Name:     "example.com/api",
+		Proxy:    "file://" + filepath.ToSlash(dir),
+		Versions: &VersionPolicy{Min: "v1.1.0"},
+	})
+	if err != nil {
+		t.Fatalf("create proxy module: %v", err)
+	}
+
+	if len(mod.Versions) != 2 {
+		t.Fatalf("got %d versions, want 2", len(mod.Versions))
+	}
+
+	for _, v := range mod.Versions {
+		if v.Commit == nil {
+			t.Errorf("the commit of %s wasn't read", v.Tag)
+		}
+	}
+
+	if mod.LatestVersion.Tag != "v1.2.0" {
+		t.Errorf("got latest version %s", mod.LatestVersion.Tag)
+	}
+
+	dropped := mod.VersionLookup["v1.0.0"]
+	if dropped == nil {
+		t.Fatal("the dropped version can't be looked up")
+	}
+
+	if dropped.Commit != nil {
+		t.Error("the dropped version was downloaded")
+	}
+
+	_, err = mod.versionCommit(dropped)
+	if err == nil {
+		t.Error("expected reading the dropped version to fail without a zip")
+	}
+}
@@ -3,7 +3,11 @@ package elephantdocs
 import (
 	"encoding/json"
 	"fmt"
+	"io"
+	"os"
+	"path/filepath"
 	"slices"
+	"strconv"
 	"strings"
 
 	"github.com/go-git/go-git/v6/plumbing/object"
@@ -17,6 +21,9 @@ type SchemaDoc struct {
 	Blocks    []BlockDoc
 	Enums     []EnumDoc
 	Policies  []PolicyDoc
+	// BlockUsage lists the block signatures that are declared by the
+	// document types.
+	BlockUsage []BlockUsageDoc
 }
 
 // SchemaSetDoc holds raw set data plus its name/title.
@@ -38,6 +45,9 @@ type DocumentDoc struct {
 	Links       []ResolvedBlock
 	Content     []ResolvedBlock
 	Deprecated  *revisor.Deprecation
+	// Examples are validated example documents, nil for document types
+	// that are extended but not declared.
+	Examples *DocumentExamples
 }
 
 // ResolvedBlock tracks where each block constraint came from.
@@ -65,6 +75,48 @@ type EnumDoc struct {
 	DeclaredIn  string
 	ExtendedBy  []string
 	Values      []EnumValueDoc
+	// UsedBy lists the attributes and data keys that reference the
+	// enum.
+	UsedBy []EnumUsage
+}
+
+// BlockUsageDoc is a block signature and the document types that declare it,
+// inline or through a reference to a block definition.
+type BlockUsageDoc struct {
+	Kind      string // "meta", "link", or "content"
+	Signature revisor.BlockSignature
+	// Documents maps the document types that declare the block to the
+	// declarations.
+	Documents map[string]*BlockUsage
+}
+
+// BlockUsage is the declaration of a block signature in a document type.
+type BlockUsage struct {
+	// Count describes the count constraints, like "1" or "0-3".
+	Count      string
+	Refs       []string
+	Deprecated bool
+	// HRef is the path of the block on the document type page, relative
+	// to the schema version root.
+	HRef string
+}
+
+// EnumUsage is an attribute or data key that references an enum.
+type EnumUsage struct {
+	// DocumentType is set for references from document types, and
+	// BlockID for references from block definitions.
+	DocumentType string
+	BlockID      string
+	// Block is the path to the block that has the attribute, empty for
+	// document attributes.
+	Block string
+	Name  string
+	Data  bool
+	// Page is the path of the document type or block definition page,
+	// relative to the schema version root, and HRef adds the anchor of
+	// the block.
+	Page string
+	HRef string
 }
 
 // EnumValueDoc represents one enum value.
@@ -99,19 +151,35 @@ func loadConstraintSets(commit *object.Commit, conf SchemaGroupConfig) ([]reviso
 		return nil, fmt.Errorf("get commit tree: %w", err)
 	}
 
+	return decodeConstraintSets(conf, func(name string) (io.ReadCloser, error) {
+		f, err := tree.File(name)
+		if err != nil {
+			return nil, err
+		}
+
+		return f.Reader()
+	})
+}
+
+// loadLocalConstraintSets reads and deserializes schema JSON files from a
+// local checkout of the schema repository.
+func loadLocalConstraintSets(dir string, conf SchemaGroupConfig) ([]revisor.ConstraintSet, error) {
+	return decodeConstraintSets(conf, func(name string) (io.ReadCloser, error) {
+		return os.Open(filepath.Join(dir, filepath.FromSlash(name)))
+	})
+}
+
+func decodeConstraintSets(
+	conf SchemaGroupConfig, open func(name string) (io.ReadCloser, error),
+) ([]revisor.ConstraintSet, error) {
 	sets := make([]revisor.ConstraintSet, 0, len(conf.Sets))
 
 	for _, sc := range conf.Sets {
-		f, err := tree.File(sc.File)
+		reader, err := open(sc.File)
 		if err != nil {
 			return nil, fmt.Errorf("open schema %q: %w", sc.File, err)
 		}
 
-		reader, err := f.Reader()
-		if err != nil {
-			return nil, fmt.Errorf("read schema %q: %w", sc.File, err)
-		}
-
 		dec := json.NewDecoder(reader)
 
 		var cs revisor.ConstraintSet
@@ -130,9 +198,103 @@ func loadConstraintSets(commit *object.Commit, conf SchemaGroupConfig) ([]reviso
 	return sets, nil
 }
 
+// copyConstraintSets copies the documents and blocks of the constraint sets,
+// so that they can be shared by code that modifies them in place.
+func copyConstraintSets(sets []revisor.ConstraintSet) []revisor.ConstraintSet {
+	result := make([]revisor.ConstraintSet, len(sets))
+
+	for i, cs := range sets {
+		cs.Documents = slices.Clone(cs.Documents)
+
+		for j := range cs.Documents {
+			dc := &cs.Documents[j]
+
+			dc.Match = copyConstraintMap(dc.Match)
+			dc.Attributes = copyConstraintMap(dc.Attributes)
+			dc.Meta = copyBlockConstraints(dc.Meta)
+			dc.Links = copyBlockConstraints(dc.Links)
+			dc.Content = copyBlockConstraints(dc.Content)
+		}
+
+		cs.Meta = copyBlockDefinitions(cs.Meta)
+		cs.Links = copyBlockDefinitions(cs.Links)
+		cs.Content = copyBlockDefinitions(cs.Content)
+
+		result[i] = cs
+	}
+
+	return result
+}
+
+func copyBlockDefinitions(defs []*revisor.BlockDefinition) []*revisor.BlockDefinition {
+	if defs == nil {
+		return nil
+	}
+
+	result := make([]*revisor.BlockDefinition, len(defs))
+
+	for i, bd := range defs {
+		if bd == nil {
+			continue
+		}
+
+		result[i] = &revisor.BlockDefinition{
+			ID:    bd.ID,
+			Block: copyBlockConstraint(bd.Block),
+		}
+	}
+
+	return result
+}
+
+func copyBlockConstraints(blocks []*revisor.BlockConstraint) []*revisor.BlockConstraint {
+	if blocks == nil {
+		return nil
+	}
+
+	result := make([]*revisor.BlockConstraint, len(blocks))
+
+	for i, bc := range blocks {
+		if bc == nil {
+			continue
+		}
+
+		c := copyBlockConstraint(*bc)
+		result[i] = &c
+	}
+
+	return result
+}
+
+func copyBlockConstraint(bc revisor.BlockConstraint) revisor.BlockConstraint {
+	bc.Match = copyConstraintMap(bc.Match)
+	bc.Attributes = copyConstraintMap(bc.Attributes)
+	bc.Data = copyConstraintMap(bc.Data)
+	bc.Meta = copyBlockConstraints(bc.Meta)
+	bc.Links = copyBlockConstraints(bc.Links)
+	bc.Content = copyBlockConstraints(bc.Content)
+
+	return bc
+}
+
+// copyConstraintMap copies a constraint map, unlike ConstraintMap.Copy it
+// keeps unset maps unset.
+func copyConstraintMap(cm revisor.ConstraintMap) revisor.ConstraintMap {
+	if cm.Constraints == nil {
+		cm.Keys = slices.Clone(cm.Keys)
+
+		return cm
+	}
+
+	return cm.Copy()
+}
+
 // resolveSchemas merges all constraint sets into a unified documentation model.
+// Match extensions of blocks are merged into the blocks that they target using
+// the merge function.
 func resolveSchemas(
 	sets []revisor.ConstraintSet, conf SchemaGroupConfig,
+	merge blockExtensionMerge,
 ) (*SchemaDoc, error) {
 	doc := &SchemaDoc{}
 
@@ -269,9 +431,9 @@ func resolveSchemas(
 
 	// Merge match block extensions into their target declares blocks.
 	for _, entry := range docsByType {
-		entry.meta = mergeBlockExtensions(entry.meta)
-		entry.links = mergeBlockExtensions(entry.links)
-		entry.content = mergeBlockExtensions(entry.content)
+		entry.meta = mergeBlockExtensions(entry.meta, merge)
+		entry.links = mergeBlockExtensions(entry.links, merge)
+		entry.content = mergeBlockExtensions(entry.content, merge)
 	}
 
 	// Build document docs from merged entries.
@@ -392,6 +554,10 @@ func resolveSchemas(
 		})
 	}
 
+	indexEnumUsage(doc)
+
+	doc.BlockUsage = indexBlockUsage(doc.Documents)
+
 	// Merge HTML policies.
 	for i, cs := range sets {
 		setName := conf.Sets[i].Name
@@ -411,6 +577,224 @@ func resolveSchemas(
 	return doc, nil
 }
 
+// indexBlockUsage indexes the block signatures that the document types
+// declare, sorted by kind and signature.
+func indexBlockUsage(documents []DocumentDoc) []BlockUsageDoc {
+	var usage []BlockUsageDoc
+
+	index := make(map[string]int)
+
+	for _, d := range documents {
+		for _, rb := range slices.Concat(d.Meta, d.Links, d.Content) {
+			sig := rb.Block.Declares
+			if sig == nil {
+				continue
+			}
+
+			key := rb.BlockKind + " " + sig.Type + " " + sig.Rel + " " + sig.Role
+
+			idx, ok := index[key]
+			if !ok {
+				idx = len(usage)
+				index[key] = idx
+
+				usage = append(usage, BlockUsageDoc{
+					Kind:      rb.BlockKind,
+					Signature: *sig,
+					Documents: make(map[string]*BlockUsage),
+				})
+			}
+
+			count := blockCountLabel(rb.Block)
+
+			u, ok := usage[idx].Documents[d.Type]
+			if !ok {
+				u = &BlockUsage{
+					Count:      count,
+					Deprecated: rb.Block.Deprecated != nil,
+					HRef: "/documents/" + d.Type + "/#block-" +
+						blockAnchor(rb.Block),
+				}
+
+				usage[idx].Documents[d.Type] = u
+			} else if u.Count != count {
+				// The signature is declared more than once.
+				u.Count += ", " + count
+			}
+
+			if rb.Ref != "" {
+				u.Refs = appendUnique(u.Refs, rb.Ref)
+			}
+		}
+	}
+
+	kinds := []string{"meta", "link", "content"}
+
+	slices.SortStableFunc(usage, func(a, b BlockUsageDoc) int {
+		if c := slices.Index(kinds, a.Kind) - slices.Index(kinds, b.Kind); c != 0 {
+			return c
+		}
+
+		return strings.Compare(
+			a.Signature.Type+" "+a.Signature.Rel+" "+a.Signature.Role,
+			b.Signature.Type+" "+b.Signature.Rel+" "+b.Signature.Role)
+	})
+
+	return usage
+}
+
+// blockCountLabel describes the count constraints of a block, like "1", "0-3"
+// or "1+".
+func blockCountLabel(bc revisor.BlockConstraint) string {
+	switch {
+	case bc.Count != nil:
+		return strconv.Itoa(*bc.Count)
+	case bc.MinCount != nil && bc.MaxCount != nil:
+		return fmt.Sprintf("%d-%d", *bc.MinCount, *bc.MaxCount)
+	case bc.MinCount != nil:
+		return fmt.Sprintf("%d+", *bc.MinCount)
+	case bc.MaxCount != nil:
+		return fmt.Sprintf("0-%d", *bc.MaxCount)
+	}
+
+	return "0+"
+}
+
+// indexEnumUsage finds the attributes and data keys of document types and
+// block definitions, including nested blocks, that reference each enum.
+func indexEnumUsage(doc *SchemaDoc) {
+	w := enumUsageWalker{
+		usage: make(map[string][]EnumUsage),
+	}
+
+	for _, d := range doc.Documents {
+		page := "/documents/" + d.Type + "/"
+
+		w.base = EnumUsage{DocumentType: d.Type}
+		w.page = page
+
+		w.collect(EnumUsage{DocumentType: d.Type, Page: page, HRef: page},
+			d.Attributes, false)
+
+		for _, rb := range slices.Concat(d.Meta, d.Links, d.Content) {
+			w.block(nil, rb.BlockKind, blockAnchor(rb.Block), rb.Block)
+		}
+	}
+
+	for _, b := range doc.Blocks {
+		w.base = EnumUsage{BlockID: b.ID}
+		w.page = "/blocks/" + b.Kind + "/" + SchemaSlug(b.ID) + "/"
+
+		w.constraints("", "", b.Block)
+		w.nested(nil, "", b.Block)
+	}
+
+	for i := range doc.Enums {
+		doc.Enums[i].UsedBy = w.usage[doc.Enums[i].ID]
+	}
+}
+
+type enumUsageWalker struct {
+	usage map[string][]EnumUsage
+	base  EnumUsage
+	page  string
+}
+
+func (w *enumUsageWalker) block(
+	path []string, kind string, anchor string, bc revisor.BlockConstraint,
+) {
+	path = append(slices.Clone(path), blockLabel(kind, bc))
+
+	w.constraints(strings.Join(path, " › "), anchor, bc)
+	w.nested(path, anchor, bc)
+}
+
+func (w *enumUsageWalker) nested(
+	path []string, anchor string, bc revisor.BlockConstraint,
+) {
+	nested := []struct {
+		kind   string
+		blocks []*revisor.BlockConstraint
+	}{
+		{"meta", bc.Meta},
+		{"link", bc.Links},
+		{"content", bc.Content},
+	}
+
+	for _, n := range nested {
+		for _, nb := range n.blocks {
+			if nb == nil {
+				continue
+			}
+
+			w.block(path, n.kind,
+				nestedBlockAnchor(anchor, n.kind, nb), *nb)
+		}
+	}
+}
+
+func (w *enumUsageWalker) constraints(
+	block string, anchor string, bc revisor.BlockConstraint,
+) {
+	u := w.base
+
+	u.Block = block
+	u.Page = w.page
+	u.HRef = w.page
+
+	if anchor != "" {
+		u.HRef += "#block-" + anchor
+	}
+
+	w.collect(u, bc.Match, false)
+	w.collect(u, bc.Attributes, false)
+	w.collect(u, bc.Data, true)
+}
+
+func (w *enumUsageWalker) collect(u EnumUsage, m revisor.ConstraintMap, data bool) {
+	for _, k := range m.Keys {
+		ref := m.Constraints[k].EnumRef
+		if ref == "" {
+			continue
+		}
+
+		u.Name = k
+		u.Data = data
+
+		if !slices.Contains(w.usage[ref], u) {
+			w.usage[ref] = append(w.usage[ref], u)
+		}
+	}
+}
+
+// blockLabel describes a block constraint by its kind and signature, like
+// "link core/image, rel image".
+func blockLabel(kind string, bc revisor.BlockConstraint) string {
+	if bc.Declares == nil {
+		if bc.Ref != "" {
+			return kind + " " + bc.Ref
+		}
+
+		return kind + " extension"
+	}
+
+	parts := []string{}
+
+	if bc.Declares.Type != "" {
+		parts = append(parts, bc.Declares.Type)
+	}
+
+	if bc.Declares.Rel != "" {
+		parts = append(parts, "rel "+bc.Declares.Rel)
+	}
+
+	if bc.Declares.Role != "" {
+		parts = append(parts, "role "+bc.Declares.Role)
+	}
+
+	return kind + " " + strings.Join(parts, ", ")
+}
+
 // resolveBlocks processes a list of block constraints, resolving refs and
 // tracking provenance.
 func resolveBlocks(
@@ -535,12 +919,14 @@ func blockMatchesDeclares(match revisor.ConstraintMap, declares *revisor.BlockSi
 // mergeBlockExtensions merges match blocks into the declares blocks they
 // target at the top level (ResolvedBlock). It also recurses into nested
 // sub-blocks within each block.
-func mergeBlockExtensions(blocks []ResolvedBlock) []ResolvedBlock {
+func mergeBlockExtensions(
+	blocks []ResolvedBlock, merge blockExtensionMerge,
+) []ResolvedBlock {
 	// First, recursively merge within each block's nested sub-blocks.
 	for i := range blocks {
-		blocks[i].Block.Meta = mergeNestedBlockExtensions(blocks[i].Block.Meta)
-		blocks[i].Block.Links = mergeNestedBlockExtensions(blocks[i].Block.Links)
-		blocks[i].Block.Content = mergeNestedBlockExtensions(blocks[i].Block.Content)
+		blocks[i].Block.Meta = mergeNestedBlockExtensions(blocks[i].Block.Meta, merge)
+		blocks[i].Block.Links = mergeNestedBlockExtensions(blocks[i].Block.Links, merge)
+		blocks[i].Block.Content = mergeNestedBlockExtensions(blocks[i].Block.Content, merge)
 	}
 
 	// Separate declares/ref blocks from match blocks at this level.
@@ -561,11 +947,7 @@ func mergeBlockExtensions(blocks []ResolvedBlock) []ResolvedBlock {
 
 		for i := range result {
 			if blockMatchesDeclares(mb.Block.Match, result[i].Block.Declares) {
-				result[i].Block.Meta = append(result[i].Block.Meta, mb.Block.Meta...)
-				result[i].Block.Links = append(result[i].Block.Links, mb.Block.Links...)
-				result[i].Block.Content = append(result[i].Block.Content, mb.Block.Content...)
-				mergeConstraintMap(&result[i].Block.Attributes, mb.Block.Attributes)
-				mergeConstraintMap(&result[i].Block.Data, mb.Block.Data)
+				merge(&result[i].Block, mb.Block)
 
 				merged = true
 
@@ -584,7 +966,9 @@ func mergeBlockExtensions(blocks []ResolvedBlock) []ResolvedBlock {
 
 // mergeNestedBlockExtensions does the same merge for nested
 // []*BlockConstraint sub-blocks, recursively.
-func mergeNestedBlockExtensions(blocks []*revisor.BlockConstraint) []*revisor.BlockConstraint {
+func mergeNestedBlockExtensions(
+	blocks []*revisor.BlockConstraint, merge blockExtensionMerge,
+) []*revisor.BlockConstraint {
 	if len(blocks) == 0 {
 		return blocks
 	}
@@ -595,9 +979,9 @@ func mergeNestedBlockExtensions(blocks []*revisor.BlockConstraint) []*revisor.Bl
 			continue
 		}
 
-		b.Meta = mergeNestedBlockExtensions(b.Meta)
-		b.Links = mergeNestedBlockExtensions(b.Links)
-		b.Content = mergeNestedBlockExtensions(b.Content)
+		b.Meta = mergeNestedBlockExtensions(b.Meta, merge)
+		b.Links = mergeNestedBlockExtensions(b.Links, merge)
+		b.Content = mergeNestedBlockExtensions(b.Content, merge)
 	}
 
 	// Separate declares blocks from match blocks.
@@ -621,11 +1005,7 @@ func mergeNestedBlockExtensions(blocks []*revisor.BlockConstraint) []*revisor.Bl
 
 		for _, target := range result {
 			if blockMatchesDeclares(mb.Match, target.Declares) {
-				target.Meta = append(target.Meta, mb.Meta...)
-				target.Links = append(target.Links, mb.Links...)
-				target.Content = append(target.Content, mb.Content...)
-				mergeConstraintMap(&target.Attributes, mb.Attributes)
-				mergeConstraintMap(&target.Data, mb.Data)
+				merge(target, *mb)
 
 				merged = true
 
@@ -641,6 +1021,21 @@ func mergeNestedBlockExtensions(blocks []*revisor.BlockConstraint) []*revisor.Bl
 	return result
 }
 
+// blockExtensionMerge merges a match extension into the block that it
+// targets.
+type blockExtensionMerge func(dst *revisor.BlockConstraint, ext revisor.BlockConstraint)
+
+// mergeBlockExtension adds the nested blocks, attributes and data keys of a
+// match extension to the block that it targets. Attributes and data keys that
+// the block already declares are documented as declared.
+func mergeBlockExtension(dst *revisor.BlockConstraint, ext revisor.BlockConstraint) {
+	dst.Meta = append(dst.Meta, ext.Meta...)
+	dst.Links = append(dst.Links, ext.Links...)
+	dst.Content = append(dst.Content, ext.Content...)
+	mergeConstraintMap(&dst.Attributes, ext.Attributes)
+	mergeConstraintMap(&dst.Data, ext.Data)
+}
+
 // mergeConstraintMap adds keys from src into dst that don't already exist.
 func mergeConstraintMap(dst *revisor.ConstraintMap, src revisor.ConstraintMap) {
 	if len(src.Keys) == 0 {
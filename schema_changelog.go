@@ -0,0 +1,123 @@
+package elephantdocs
+
+import (
+	"fmt"
+	"path"
+	"slices"
+
+	"github.com/go-git/go-git/v6"
+	"github.com/ttab/elephant-docs/internal"
+)
+
+// SchemaChangelog is the commit history of the schema repository by version,
+// in descending order, for all sets and for each set.
+type SchemaChangelog struct {
+	All  []*ModuleVersion
+	Sets map[string][]*ModuleVersion
+	// Links are the links to the schema repository.
+	Links SourceLinks `json:"-"`
+}
+
+// Until returns the changelog up to and including a version.
+func (c *SchemaChangelog) Until(tag string) *SchemaChangelog {
+	until := func(versions []*ModuleVersion) []*ModuleVersion {
+		idx := slices.IndexFunc(versions, func(v *ModuleVersion) bool {
+			return v.Tag == tag
+		})
+		if idx == -1 {
+			return nil
+		}
+
+		return versions[idx:]
+	}
+
+	result := SchemaChangelog{
+		All:   until(c.All),
+		Sets:  make(map[string][]*ModuleVersion, len(c.Sets)),
+		Links: c.Links,
+	}
+
+	for name, versions := range c.Sets {
+		result.Sets[name] = until(versions)
+	}
+
+	return &result
+}
+
+// getSchemaChangelog lists the commits that changed the constraint set files
+// for each version. Versions must be sorted in descending order.
+func getSchemaChangelog(
+	repo *git.Repository, conf SchemaGroupConfig, versions []*ModuleVersion,
+) (*SchemaChangelog, error) {
+	links, err := newSourceLinks(ModuleConfig{
+		Name:  conf.Repo,
+		Links: conf.Links,
+	}, schemaCloneURL(conf))
+	if err != nil {
+		return nil, fmt.Errorf("configure source links: %w", err)
+	}
+
+	changelog := SchemaChangelog{
+		Sets:  make(map[string][]*ModuleVersion, len(conf.Sets)),
+		Links: links,
+	}
+
+	if len(versions) == 0 {
+		return &changelog, nil
+	}
+
+	filter, err := newChangelogFilter(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	collect := func(files []string) ([]*ModuleVersion, error) {
+		log := make([]*ModuleVersion, len(versions))
+
+		// Copy the versions so that each log has its own entries.
+		for i := range versions {
+			v := *versions[i]
+			v.Log = nil
+			log[i] = &v
+		}
+
+		err := collectVersionLogs(repo, log, internal.AnyPath(
+			func(name string) bool {
+				return slices.Contains(files, name)
+			}))
+		if err != nil {
+			return nil, err
+		}
+
+		for _, v := range log {
+			v.Groups = groupChangelog(v.Log, filter)
+		}
+
+		return log, nil
+	}
+
+	var allFiles []string
+
+	for _, set := range conf.Sets {
+		file := path.Clean(set.File)
+
+		allFiles = append(allFiles, file)
+
+		log, err := collect([]string{file})
+		if err != nil {
+			return nil, fmt.Errorf("collect changelog for %q: %w",
+				set.Name, err)
+		}
+
+		changelog.Sets[set.Name] = log
+	}
+
+	all, err := collect(allFiles)
+	if err != nil {
+		return nil, fmt.Errorf("collect changelog: %w", err)
+	}
+
+	changelog.All = all
+
+	return &changelog, nil
+}
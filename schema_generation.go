@@ -4,108 +4,277 @@ import (
 	"encoding/json"
 	"fmt"
 	"html/template"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 
+	"github.com/ttab/elephant-docs/internal"
 	"github.com/ttab/revisor"
 )
 
+// SchemaVersion is a documented version of the schemas.
+type SchemaVersion struct {
+	Tag          string
+	IsPrerelease bool
+	IsLatest     bool
+	Doc          *SchemaDoc
+	// Changes since the previous documented version, nil for the
+	// oldest version.
+	Changes *SchemaDiff
+	// Changelog is the commit history up to and including the version.
+	Changelog *SchemaChangelog
+
+	version *ModuleVersion
+	// constraints are the unresolved constraint sets, loaded if the
+	// playground is enabled.
+	constraints []revisor.ConstraintSet
+	// pages are the paths of the pages of the version, relative to the
+	// root of the version.
+	pages map[string]bool
+}
+
+// SchemaVersionLink links to the same page in another schema version, or to
+// the overview of the version if it doesn't have the page.
+type SchemaVersionLink struct {
+	Tag          string
+	HRef         string
+	IsPrerelease bool
+	IsLatest     bool
+	IsCurrent    bool
+}
+
 // SchemaOverviewPage is the data for the schema overview template.
 type SchemaOverviewPage struct {
-	Title     string
-	Version   string
-	Documents []DocumentDoc
-	Blocks    []BlockDoc
-	Enums     []EnumDoc
-	Policies  []PolicyDoc
-	Sets      []SchemaSetDoc
+	Title    string
+	Version  string
+	Versions []SchemaVersionLink
+	// PreviousVersion is set if there are changes since a previous
+	// version.
+	PreviousVersion string
+	Documents       []DocumentDoc
+	Blocks          []BlockDoc
+	Enums           []EnumDoc
+	Policies        []PolicyDoc
+	Sets            []SchemaSetDoc
 }
 
 // SchemaSetPage is the data for the schema set detail template.
 type SchemaSetPage struct {
-	Version string
-	Set     SchemaSetDoc
+	Version   string
+	Versions  []SchemaVersionLink
+	Set       SchemaSetDoc
+	Changelog []*ModuleVersion
+	Links     SourceLinks `json:"-"`
+}
+
+// SchemaChangelogPage is the data for the schema changelog template.
+type SchemaChangelogPage struct {
+	Version   string
+	Versions  []SchemaVersionLink
+	Changelog []*ModuleVersion
+	Links     SourceLinks `json:"-"`
+}
+
+// SchemaBlockMatrixPage is the data for the block matrix template.
+type SchemaBlockMatrixPage struct {
+	Version   string
+	Versions  []SchemaVersionLink
+	Documents []DocumentDoc
+	Sections  []BlockMatrixSection
+}
+
+// BlockMatrixSection lists the block signatures of one kind of block.
+type BlockMatrixSection struct {
+	Title  string
+	Kind   string
+	Blocks []BlockUsageDoc
 }
 
 // SchemaDocumentPage is the data for the document type template.
 type SchemaDocumentPage struct {
-	Version  string
-	Document DocumentDoc
-	Enums    []EnumDoc
-	Blocks   []BlockDoc
+	Version         string
+	Versions        []SchemaVersionLink
+	PreviousVersion string
+	Document        DocumentDoc
+	Enums           []EnumDoc
+	Blocks          []BlockDoc
 }
 
 // SchemaBlockPage is the data for the block definition template.
 type SchemaBlockPage struct {
-	Version string
-	Block   BlockDoc
-	Enums   []EnumDoc
+	Version  string
+	Versions []SchemaVersionLink
+	Block    BlockDoc
+	Enums    []EnumDoc
 }
 
 // SchemaEnumPage is the data for the enum template.
 type SchemaEnumPage struct {
-	Version string
-	Enum    EnumDoc
+	Version  string
+	Versions []SchemaVersionLink
+	Enum     EnumDoc
+}
+
+// SchemaChangesPage is the data for the schema changes template.
+type SchemaChangesPage struct {
+	Version  string
+	Versions []SchemaVersionLink
+	Diff     *SchemaDiff
+	// DocumentType is set when listing the changes to a document type.
+	DocumentType string
+	Changes      []SchemaChange
 }
 
 // SchemaPolicyPage is the data for the HTML policy template.
 type SchemaPolicyPage struct {
-	Version string
-	Policy  PolicyDoc
+	Version  string
+	Versions []SchemaVersionLink
+	Policy   PolicyDoc
 }
 
-// renderSchemaPages generates all schema documentation pages.
+// renderSchemaPages generates all schema documentation pages for a schema
+// version. The pages are rendered under the root path, which is either
+// "/schemas/<tag>", or "/schemas" for the latest version.
 func renderSchemaPages(
 	outDir string,
-	doc *SchemaDoc,
 	tpl *template.Template,
 	menu []MenuItem,
-	version string,
+	versions []*SchemaVersion,
+	current *SchemaVersion,
+	root string,
 ) error {
 	localTpl, err := tpl.Clone()
 	if err != nil {
 		return fmt.Errorf("clone templates: %w", err)
 	}
 
-	// Render overview page.
-	overviewDir := filepath.Join(outDir, "schemas")
+	localTpl.Funcs(template.FuncMap{
+		"schema_url": func(p string) string {
+			return root + p
+		},
+	})
+
+	doc := current.Doc
+	version := current.Tag
+	outDir = filepath.Join(outDir, filepath.FromSlash(root))
+	menu = withSchemaMenu(menu, schemaMenu(doc, root, current.constraints != nil))
+
+	links := func(page string) []SchemaVersionLink {
+		return schemaVersionLinks(versions, current, page)
+	}
+
+	var previous string
+
+	if current.Changes != nil {
+		previous = current.Changes.From
+	}
 
-	err = renderPage(overviewDir, localTpl, "schema_overview.html", Page{
+	// Render overview page.
+	err = renderPage(outDir, localTpl, "schema_overview.html", Page{
 		Title: "Document Schemas",
-		Menu:  markActive(menu, "/schemas"),
+		Menu:  markActive(menu, root),
 		Contents: SchemaOverviewPage{
-			Title:     "Document Schemas",
-			Version:   version,
-			Documents: doc.Documents,
-			Blocks:    doc.Blocks,
-			Enums:     doc.Enums,
-			Policies:  doc.Policies,
-			Sets:      doc.Sets,
+			Title:           "Document Schemas",
+			Version:         version,
+			Versions:        links("/"),
+			PreviousVersion: previous,
+			Documents:       doc.Documents,
+			Blocks:          doc.Blocks,
+			Enums:           doc.Enums,
+			Policies:        doc.Policies,
+			Sets:            doc.Sets,
 		},
 		Breadcrumb: []MenuItem{
 			{Title: "Home", HRef: "/"},
-			{Title: "Schemas"},
+			{Title: "Schemas", HRef: root},
+			{Title: version},
 		},
 	})
 	if err != nil {
 		return fmt.Errorf("render schema overview: %w", err)
 	}
 
+	err = internal.MarshalFile(filepath.Join(outDir, "schema.json"),
+		bundleJSONSchema(doc, version))
+	if err != nil {
+		return fmt.Errorf("write JSON schema bundle: %w", err)
+	}
+
+	err = os.WriteFile(filepath.Join(outDir, "newsdoc.d.ts"),
+		[]byte(schemaTypeScript(doc, version)), 0o644)
+	if err != nil {
+		return fmt.Errorf("write TypeScript types: %w", err)
+	}
+
+	// Render the changelog.
+	err = renderPage(filepath.Join(outDir, "changelog"), localTpl,
+		"schema_changelog.html", Page{
+			Title: "Schema Changelog",
+			Menu:  markActive(menu, root+"/changelog"),
+			Contents: SchemaChangelogPage{
+				Version:   version,
+				Versions:  links("/changelog/"),
+				Changelog: current.Changelog.All,
+				Links:     current.Changelog.Links,
+			},
+			Breadcrumb: []MenuItem{
+				{Title: "Home", HRef: "/"},
+				{Title: "Schemas", HRef: root},
+				{Title: "Changelog"},
+			},
+		})
+	if err != nil {
+		return fmt.Errorf("render schema changelog: %w", err)
+	}
+
+	// Render the block matrix.
+	err = renderPage(filepath.Join(outDir, "block-matrix"), localTpl,
+		"schema_block_matrix.html", Page{
+			Title: "Block Matrix",
+			Menu:  markActive(menu, root+"/block-matrix"),
+			Contents: SchemaBlockMatrixPage{
+				Version:   version,
+				Versions:  links("/block-matrix/"),
+				Documents: doc.Documents,
+				Sections:  blockMatrixSections(doc.BlockUsage),
+			},
+			Breadcrumb: []MenuItem{
+				{Title: "Home", HRef: "/"},
+				{Title: "Schemas", HRef: root},
+				{Title: "Block Matrix"},
+			},
+		})
+	if err != nil {
+		return fmt.Errorf("render block matrix: %w", err)
+	}
+
+	if current.constraints != nil {
+		err := renderSchemaPlayground(outDir, localTpl, menu, current,
+			root, links("/playground/"))
+		if err != nil {
+			return fmt.Errorf("render schema playground: %w", err)
+		}
+	}
+
 	// Render set detail pages.
 	for _, set := range doc.Sets {
-		setDir := filepath.Join(outDir, "schemas", set.Name)
+		setDir := filepath.Join(outDir, set.Name)
 
 		err = renderPage(setDir, localTpl, "schema_set.html", Page{
 			Title: set.Title + " Schema Set",
-			Menu:  markActive(menu, "/schemas/"+set.Name),
+			Menu:  markActive(menu, root+"/"+set.Name),
 			Contents: SchemaSetPage{
-				Version: version,
-				Set:     set,
+				Version:   version,
+				Versions:  links("/" + set.Name + "/"),
+				Set:       set,
+				Changelog: current.Changelog.Sets[set.Name],
+				Links:     current.Changelog.Links,
 			},
 			Breadcrumb: []MenuItem{
 				{Title: "Home", HRef: "/"},
-				{Title: "Schemas", HRef: "/schemas"},
+				{Title: "Schemas", HRef: root},
 				{Title: set.Title},
 			},
 		})
@@ -117,7 +286,7 @@ func renderSchemaPages(
 	// Render document type pages.
 	for _, d := range doc.Documents {
 		docSlug := d.Type
-		docDir := filepath.Join(outDir, "schemas", "documents", docSlug)
+		docDir := filepath.Join(outDir, "documents", docSlug)
 
 		err = os.MkdirAll(docDir, 0o770)
 		if err != nil {
@@ -126,28 +295,40 @@ func renderSchemaPages(
 
 		err = renderPage(docDir, localTpl, "schema_document.html", Page{
 			Title: docDisplayName(d),
-			Menu:  markActive(menu, "/schemas/documents/"+d.Type),
+			Menu:  markActive(menu, root+"/documents/"+d.Type),
 			Contents: SchemaDocumentPage{
-				Version:  version,
-				Document: d,
-				Enums:    doc.Enums,
-				Blocks:   doc.Blocks,
+				Version:         version,
+				Versions:        links("/documents/" + docSlug + "/"),
+				PreviousVersion: previous,
+				Document:        d,
+				Enums:           doc.Enums,
+				Blocks:          doc.Blocks,
 			},
 			Breadcrumb: []MenuItem{
 				{Title: "Home", HRef: "/"},
-				{Title: "Schemas", HRef: "/schemas"},
+				{Title: "Schemas", HRef: root},
 				{Title: docDisplayName(d)},
 			},
 		})
 		if err != nil {
 			return fmt.Errorf("render schema document %q: %w", d.Type, err)
 		}
+
+		if d.DeclaredIn == "" {
+			continue
+		}
+
+		err = internal.MarshalFile(filepath.Join(docDir, "schema.json"),
+			documentJSONSchema(d, doc.Enums))
+		if err != nil {
+			return fmt.Errorf("write JSON schema for %q: %w", d.Type, err)
+		}
 	}
 
 	// Render block definition pages.
 	for _, b := range doc.Blocks {
 		blockSlug := SchemaSlug(b.ID)
-		blockDir := filepath.Join(outDir, "schemas", "blocks", b.Kind, blockSlug)
+		blockDir := filepath.Join(outDir, "blocks", b.Kind, blockSlug)
 
 		err = os.MkdirAll(blockDir, 0o770)
 		if err != nil {
@@ -156,15 +337,16 @@ func renderSchemaPages(
 
 		err = renderPage(blockDir, localTpl, "schema_block.html", Page{
 			Title: b.ID,
-			Menu:  markActive(menu, "/schemas/blocks/"+b.Kind+"/"+SchemaSlug(b.ID)),
+			Menu:  markActive(menu, root+"/blocks/"+b.Kind+"/"+blockSlug),
 			Contents: SchemaBlockPage{
-				Version: version,
-				Block:   b,
-				Enums:   doc.Enums,
+				Version:  version,
+				Versions: links("/blocks/" + b.Kind + "/" + blockSlug + "/"),
+				Block:    b,
+				Enums:    doc.Enums,
 			},
 			Breadcrumb: []MenuItem{
 				{Title: "Home", HRef: "/"},
-				{Title: "Schemas", HRef: "/schemas"},
+				{Title: "Schemas", HRef: root},
 				{Title: "Blocks"},
 				{Title: b.ID},
 			},
@@ -177,7 +359,7 @@ func renderSchemaPages(
 	// Render enum pages.
 	for _, e := range doc.Enums {
 		enumSlug := SchemaSlug(e.ID)
-		enumDir := filepath.Join(outDir, "schemas", "enums", enumSlug)
+		enumDir := filepath.Join(outDir, "enums", enumSlug)
 
 		err = os.MkdirAll(enumDir, 0o770)
 		if err != nil {
@@ -186,14 +368,15 @@ func renderSchemaPages(
 
 		err = renderPage(enumDir, localTpl, "schema_enum.html", Page{
 			Title: enumDisplayName(e),
-			Menu:  markActive(menu, "/schemas/enums/"+SchemaSlug(e.ID)),
+			Menu:  markActive(menu, root+"/enums/"+enumSlug),
 			Contents: SchemaEnumPage{
-				Version: version,
-				Enum:    e,
+				Version:  version,
+				Versions: links("/enums/" + enumSlug + "/"),
+				Enum:     e,
 			},
 			Breadcrumb: []MenuItem{
 				{Title: "Home", HRef: "/"},
-				{Title: "Schemas", HRef: "/schemas"},
+				{Title: "Schemas", HRef: root},
 				{Title: "Enums"},
 				{Title: enumDisplayName(e)},
 			},
@@ -203,9 +386,18 @@ func renderSchemaPages(
 		}
 	}
 
+	// Render the changes since the previous version.
+	if current.Changes != nil {
+		err = renderSchemaChangesPages(
+			outDir, localTpl, menu, root, current, links)
+		if err != nil {
+			return err
+		}
+	}
+
 	// Render HTML policy pages.
 	for _, p := range doc.Policies {
-		policyDir := filepath.Join(outDir, "schemas", "policies", p.Name)
+		policyDir := filepath.Join(outDir, "policies", p.Name)
 
 		err = os.MkdirAll(policyDir, 0o770)
 		if err != nil {
@@ -214,14 +406,15 @@ func renderSchemaPages(
 
 		err = renderPage(policyDir, localTpl, "schema_policy.html", Page{
 			Title: "HTML Policy: " + p.Name,
-			Menu:  markActive(menu, "/schemas/policies/"+p.Name),
+			Menu:  markActive(menu, root+"/policies/"+p.Name),
 			Contents: SchemaPolicyPage{
-				Version: version,
-				Policy:  p,
+				Version:  version,
+				Versions: links("/policies/" + p.Name + "/"),
+				Policy:   p,
 			},
 			Breadcrumb: []MenuItem{
 				{Title: "Home", HRef: "/"},
-				{Title: "Schemas", HRef: "/schemas"},
+				{Title: "Schemas", HRef: root},
 				{Title: "Policies"},
 				{Title: p.Name},
 			},
@@ -234,6 +427,374 @@ func renderSchemaPages(
 	return nil
 }
 
+func renderSchemaChangesPages(
+	outDir string,
+	tpl *template.Template,
+	menu []MenuItem,
+	root string,
+	current *SchemaVersion,
+	links func(page string) []SchemaVersionLink,
+) error {
+	diff := current.Changes
+
+	err := renderPage(filepath.Join(outDir, "changes"), tpl,
+		"schema_changes.html", Page{
+			Title: "Schema changes in " + current.Tag,
+			Menu:  markActive(menu, root+"/changes"),
+			Contents: SchemaChangesPage{
+				Version:  current.Tag,
+				Versions: links("/changes/"),
+				Diff:     diff,
+			},
+			Breadcrumb: []MenuItem{
+				{Title: "Home", HRef: "/"},
+				{Title: "Schemas", HRef: root},
+				{Title: "Changes"},
+			},
+		})
+	if err != nil {
+		return fmt.Errorf("render schema changes: %w", err)
+	}
+
+	for _, d := range current.Doc.Documents {
+		docPath := "/documents/" + d.Type
+
+		err := renderPage(
+			filepath.Join(outDir, filepath.FromSlash(docPath), "changes"),
+			tpl, "schema_changes.html", Page{
+				Title: docDisplayName(d) + " changes in " + current.Tag,
+				Menu:  markActive(menu, root+docPath),
+				Contents: SchemaChangesPage{
+					Version:      current.Tag,
+					Versions:     links(docPath + "/changes/"),
+					Diff:         diff,
+					DocumentType: d.Type,
+					Changes:      diff.ForDocument(d.Type),
+				},
+				Breadcrumb: []MenuItem{
+					{Title: "Home", HRef: "/"},
+					{Title: "Schemas", HRef: root},
+					{Title: docDisplayName(d), HRef: root + docPath},
+					{Title: "Changes"},
+				},
+			})
+		if err != nil {
+			return fmt.Errorf("render schema changes for %q: %w",
+				d.Type, err)
+		}
+	}
+
+	return nil
+}
+
+// loadSchemaVersions resolves the schemas of each version. Versions other than
+// the latest that can't be loaded are skipped, and invalid examples only fail
+// the latest version.
+func loadSchemaVersions(
+	conf SchemaGroupConfig,
+	versions []*ModuleVersion, latest *ModuleVersion,
+) ([]*SchemaVersion, error) {
+	var result []*SchemaVersion
+
+	for _, v := range versions {
+		doc, sets, err := loadSchemaDoc(conf, v)
+
+		switch {
+		case err != nil && v == latest:
+			return nil, fmt.Errorf("load schema version %s: %w", v.Tag, err)
+		case err != nil:
+			slog.Warn("skipping schema version",
+				"version", v.Tag,
+				"err", err)
+
+			continue
+		}
+
+		if v == latest {
+			err := exampleProblems(doc)
+			if err != nil {
+				return nil, fmt.Errorf(
+					"invalid examples in schema version %s: %w",
+					v.Tag, err)
+			}
+		}
+
+		for _, d := range doc.Documents {
+			if d.Examples == nil || len(d.Examples.Problems) == 0 {
+				continue
+			}
+
+			slog.Warn("invalid document examples",
+				"version", v.Tag,
+				"document", d.Type,
+				"problems", d.Examples.Problems)
+		}
+
+		sv := SchemaVersion{
+			Tag:          v.Tag,
+			IsPrerelease: v.IsPrerelease,
+			IsLatest:     v == latest,
+			Doc:          doc,
+			version:      v,
+		}
+
+		if conf.Playground {
+			sv.constraints = sets
+		}
+
+		result = append(result, &sv)
+	}
+
+	// Versions are in descending order, so each version is compared to
+	// the one that follows it.
+	for i, sv := range result {
+		if i+1 < len(result) {
+			prev := result[i+1]
+
+			sv.Changes = diffSchemas(prev.Tag, prev.Doc, sv.Tag, sv.Doc)
+		}
+
+		sv.pages = schemaPagePaths(sv)
+	}
+
+	return result, nil
+}
+
+// loadSchemaDoc resolves the schemas of a version with their examples. The
+// decoded constraint sets are returned as well, unmodified by the resolution.
+func loadSchemaDoc(
+	conf SchemaGroupConfig, v *ModuleVersion,
+) (*SchemaDoc, []revisor.ConstraintSet, error) {
+	sets, err := loadConstraintSets(v.Commit, conf)
+	if err != nil {
+		return nil, nil, fmt.Errorf("load constraint sets: %w", err)
+	}
+
+	doc, validator, err := resolveSchemaSource(conf, sets)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Examples are generated from schemas where the block extensions
+	// narrow the constraints of the blocks that they extend.
+	exampleDoc, err := resolveSchemas(
+		copyConstraintSets(sets), conf, narrowBlockExtension)
+	if err != nil {
+		return nil, nil, fmt.Errorf("resolve example schemas: %w", err)
+	}
+
+	addDocumentExamples(doc, exampleDoc, validator)
+
+	return doc, sets, nil
+}
+
+// resolveSchemaSource resolves the constraint sets and creates a validator for
+// them. Both work on copies, as revisor resolves the block references of the
+// sets in place and resolveSchemas merges block extensions into them.
+func resolveSchemaSource(
+	conf SchemaGroupConfig, sets []revisor.ConstraintSet,
+) (*SchemaDoc, *revisor.Validator, error) {
+	validator, err := revisor.NewValidator(copyConstraintSets(sets)...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("create validator: %w", err)
+	}
+
+	doc, err := resolveSchemas(copyConstraintSets(sets), conf, mergeBlockExtension)
+	if err != nil {
+		return nil, nil, fmt.Errorf("resolve schemas: %w", err)
+	}
+
+	return doc, validator, nil
+}
+
+func blockMatrixSections(usage []BlockUsageDoc) []BlockMatrixSection {
+	sections := []BlockMatrixSection{
+		{Title: "Meta Blocks", Kind: "meta"},
+		{Title: "Link Blocks", Kind: "link"},
+		{Title: "Content Blocks", Kind: "content"},
+	}
+
+	for i := range sections {
+		for _, u := range usage {
+			if u.Kind == sections[i].Kind {
+				sections[i].Blocks = append(sections[i].Blocks, u)
+			}
+		}
+	}
+
+	return sections
+}
+
+// schemaPagePaths lists the paths of the pages of a schema version, relative
+// to the root of the version.
+func schemaPagePaths(v *SchemaVersion) map[string]bool {
+	doc := v.Doc
+	paths := map[string]bool{
+		"/": true, "/changelog/": true, "/block-matrix/": true,
+	}
+
+	if v.constraints != nil {
+		paths["/playground/"] = true
+	}
+
+	if v.Changes != nil {
+		paths["/changes/"] = true
+
+		for _, d := range doc.Documents {
+			paths["/documents/"+d.Type+"/changes/"] = true
+		}
+	}
+
+	for _, set := range doc.Sets {
+		paths["/"+set.Name+"/"] = true
+	}
+
+	for _, d := range doc.Documents {
+		paths["/documents/"+d.Type+"/"] = true
+	}
+
+	for _, b := range doc.Blocks {
+		paths["/blocks/"+b.Kind+"/"+SchemaSlug(b.ID)+"/"] = true
+	}
+
+	for _, e := range doc.Enums {
+		paths["/enums/"+SchemaSlug(e.ID)+"/"] = true
+	}
+
+	for _, p := range doc.Policies {
+		paths["/policies/"+p.Name+"/"] = true
+	}
+
+	return paths
+}
+
+// schemaVersionLinks links a page to the same page in all schema versions.
+func schemaVersionLinks(
+	versions []*SchemaVersion, current *SchemaVersion, page string,
+) []SchemaVersionLink {
+	links := make([]SchemaVersionLink, len(versions))
+
+	for i, v := range versions {
+		href := "/schemas/" + v.Tag + "/"
+
+		if v.pages[page] {
+			href = "/schemas/" + v.Tag + page
+		}
+
+		links[i] = SchemaVersionLink{
+			Tag:          v.Tag,
+			HRef:         href,
+			IsPrerelease: v.IsPrerelease,
+			IsLatest:     v.IsLatest,
+			IsCurrent:    v == current,
+		}
+	}
+
+	return links
+}
+
+// schemaMenu creates the schemas menu section for a schema version.
+func schemaMenu(doc *SchemaDoc, root string, playground bool) MenuItem {
+	item := MenuItem{
+		Title: "Schemas",
+	}
+
+	item.Children = append(item.Children, MenuItem{
+		Title: "Overview",
+		HRef:  root,
+	}, MenuItem{
+		Title: "Changelog",
+		HRef:  root + "/changelog",
+	}, MenuItem{
+		Title: "Block Matrix",
+		HRef:  root + "/block-matrix",
+	})
+
+	if playground {
+		item.Children = append(item.Children, MenuItem{
+			Title: "Playground",
+			HRef:  root + "/playground",
+		})
+	}
+
+	docTypesItem := MenuItem{
+		Title: "Document Types",
+	}
+
+	for _, d := range doc.Documents {
+		docTypesItem.Children = append(docTypesItem.Children, MenuItem{
+			Title: docDisplayName(d),
+			HRef:  fmt.Sprintf("%s/documents/%s", root, d.Type),
+		})
+	}
+
+	item.Children = append(item.Children, docTypesItem)
+
+	for _, kindInfo := range []struct {
+		kind  string
+		title string
+	}{
+		{"meta", "Meta Blocks"},
+		{"link", "Link Blocks"},
+		{"content", "Content Blocks"},
+	} {
+		kindItem := MenuItem{
+			Title: kindInfo.title,
+		}
+
+		for _, b := range doc.Blocks {
+			if b.Kind != kindInfo.kind {
+				continue
+			}
+
+			name := b.Block.Name
+			if name == "" {
+				name = b.ID
+			}
+
+			kindItem.Children = append(kindItem.Children, MenuItem{
+				Title: name,
+				HRef: fmt.Sprintf("%s/blocks/%s/%s",
+					root, b.Kind, SchemaSlug(b.ID)),
+			})
+		}
+
+		if len(kindItem.Children) > 0 {
+			item.Children = append(item.Children, kindItem)
+		}
+	}
+
+	if len(doc.Policies) > 0 {
+		policiesItem := MenuItem{
+			Title: "HTML Policies",
+		}
+
+		for _, p := range doc.Policies {
+			policiesItem.Children = append(policiesItem.Children, MenuItem{
+				Title: p.Name,
+				HRef:  fmt.Sprintf("%s/policies/%s", root, p.Name),
+			})
+		}
+
+		item.Children = append(item.Children, policiesItem)
+	}
+
+	return item
+}
+
+// withSchemaMenu returns a copy of the menu with the schemas section replaced.
+func withSchemaMenu(menu []MenuItem, item MenuItem) []MenuItem {
+	menu = slices.Clone(menu)
+
+	for i := range menu {
+		if menu[i].Title == item.Title {
+			menu[i] = item
+		}
+	}
+
+	return menu
+}
+
 func docDisplayName(d DocumentDoc) string {
 	if d.Name != "" {
 		return d.Name
@@ -265,7 +826,10 @@ func schemaShortName(name string) string {
 // schemaTemplateFuncs returns template functions for schema pages.
 func schemaTemplateFuncs() template.FuncMap {
 	return template.FuncMap{
-		"schema_slug":       SchemaSlug,
+		"schema_slug": SchemaSlug,
+		"schema_url": func(p string) string {
+			return "/schemas" + p
+		},
 		"schema_doc_name":   docDisplayName,
 		"schema_enum_name":  enumDisplayName,
 		"schema_short_name": schemaShortName,
@@ -282,33 +846,14 @@ func schemaTemplateFuncs() template.FuncMap {
 			}
 			return *p
 		},
-		"block_anchor": func(bc revisor.BlockConstraint) string {
-			if bc.Declares != nil {
-				parts := []string{}
-				if bc.Declares.Type != "" {
-					parts = append(parts, bc.Declares.Type)
-				}
-				if bc.Declares.Rel != "" {
-					parts = append(parts, bc.Declares.Rel)
-				}
-				if bc.Declares.Role != "" {
-					parts = append(parts, bc.Declares.Role)
-				}
-
-				anchor := strings.Join(parts, "-")
-				anchor = strings.ReplaceAll(anchor, "/", "-")
-				anchor = strings.ReplaceAll(anchor, " ", "-")
-
-				return anchor
-			}
-
-			return ""
-		},
+		"block_anchor":   blockAnchor,
 		"match_doc_type": matchDocType,
-		"nested_block_ctx": func(kind string, bc *revisor.BlockConstraint) map[string]interface{} {
+		"example_json":   exampleJSON,
+		"nested_block_ctx": func(parent string, kind string, bc *revisor.BlockConstraint) map[string]interface{} {
 			return map[string]interface{}{
-				"Kind":  kind,
-				"Block": bc,
+				"Kind":   kind,
+				"Block":  bc,
+				"Anchor": nestedBlockAnchor(parent, kind, bc),
 			}
 		},
 		"glob_patterns": func(gl revisor.GlobList) string {
@@ -325,6 +870,52 @@ func schemaTemplateFuncs() template.FuncMap {
 	}
 }
 
+// blockAnchor returns the anchor of a block declaration on the document type
+// page.
+func blockAnchor(bc revisor.BlockConstraint) string {
+	if bc.Declares == nil {
+		return ""
+	}
+
+	parts := []string{}
+	if bc.Declares.Type != "" {
+		parts = append(parts, bc.Declares.Type)
+	}
+	if bc.Declares.Rel != "" {
+		parts = append(parts, bc.Declares.Rel)
+	}
+	if bc.Declares.Role != "" {
+		parts = append(parts, bc.Declares.Role)
+	}
+
+	anchor := strings.Join(parts, "-")
+	anchor = strings.ReplaceAll(anchor, "/", "-")
+	anchor = strings.ReplaceAll(anchor, " ", "-")
+
+	return anchor
+}
+
+// nestedBlockAnchor returns the anchor of a nested block declaration, made
+// unique on the page by prefixing it with the anchor of the parent block.
+func nestedBlockAnchor(parent string, kind string, bc *revisor.BlockConstraint) string {
+	if bc == nil {
+		return ""
+	}
+
+	anchor := blockAnchor(*bc)
+	if anchor == "" {
+		return ""
+	}
+
+	anchor = kind + "-" + anchor
+
+	if parent != "" {
+		anchor = parent + "--" + anchor
+	}
+
+	return anchor
+}
+
 // SchemaCard represents a schema document type for the home page.
 type SchemaCard struct {
 	Name        string
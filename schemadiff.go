@@ -0,0 +1,533 @@
+package elephantdocs
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/ttab/revisor"
+)
+
+// SchemaChange is a change between two schema versions.
+type SchemaChange struct {
+	// Change is "added", "removed" or "changed".
+	Change string
+	// Kind is the kind of schema item, like "document", "block",
+	// "attribute", "data", "count", "enum value" or "element".
+	Kind string
+	// Path locates the item, like "core/article > meta
+	// type=core/newsvalue > data.score".
+	Path string
+	// Detail describes changed items, like "min 0 to min 1".
+	Detail string `json:",omitempty"`
+	// DocumentType is set for changes to a document type.
+	DocumentType string `json:",omitempty"`
+}
+
+func (c SchemaChange) String() string {
+	s := fmt.Sprintf("%s %s `%s`", c.Change, c.Kind, c.Path)
+
+	if c.Detail != "" {
+		s += ": " + c.Detail
+	}
+
+	return s
+}
+
+// SchemaDiff lists the changes between two schema versions.
+type SchemaDiff struct {
+	From      string
+	To        string
+	Documents []SchemaChange `json:",omitempty"`
+	Blocks    []SchemaChange `json:",omitempty"`
+	Enums     []SchemaChange `json:",omitempty"`
+	Policies  []SchemaChange `json:",omitempty"`
+}
+
+// IsEmpty returns true if there were no changes.
+func (d *SchemaDiff) IsEmpty() bool {
+	return len(d.Documents) == 0 && len(d.Blocks) == 0 &&
+		len(d.Enums) == 0 && len(d.Policies) == 0
+}
+
+// ForDocument returns the changes to a document type.
+func (d *SchemaDiff) ForDocument(docType string) []SchemaChange {
+	var changes []SchemaChange
+
+	for _, c := range d.Documents {
+		if c.DocumentType == docType {
+			changes = append(changes, c)
+		}
+	}
+
+	return changes
+}
+
+// DiffSchemaTags compares the schemas of two version tags of the schema
+// repository.
+func DiffSchemaTags(conf SchemaGroupConfig, from, to string) (*SchemaDiff, error) {
+	_, versions, err := listSchemaVersions(conf)
+	if err != nil {
+		return nil, fmt.Errorf("list schema versions: %w", err)
+	}
+
+	docs := make([]*SchemaDoc, 2)
+
+	for i, tag := range []string{from, to} {
+		idx := slices.IndexFunc(versions, func(v *ModuleVersion) bool {
+			return v.Tag == tag
+		})
+		if idx == -1 {
+			return nil, fmt.Errorf("unknown schema version %q", tag)
+		}
+
+		// The diff doesn't use the examples, so the schemas are
+		// resolved without generating them.
+		sets, err := loadConstraintSets(versions[idx].Commit, conf)
+		if err != nil {
+			return nil, fmt.Errorf("load schema version %s: %w", tag, err)
+		}
+
+		doc, err := resolveSchemas(sets, conf, mergeBlockExtension)
+		if err != nil {
+			return nil, fmt.Errorf("resolve schema version %s: %w", tag, err)
+		}
+
+		docs[i] = doc
+	}
+
+	return diffSchemas(from, docs[0], to, docs[1]), nil
+}
+
+// diffSchemas compares the document types, block definitions, enums and HTML
+// policies of two schema versions.
+func diffSchemas(fromTag string, from *SchemaDoc, toTag string, to *SchemaDoc) *SchemaDiff {
+	d := SchemaDiff{
+		From: fromTag,
+		To:   toTag,
+	}
+
+	d.Documents = diffByKey(from.Documents, to.Documents,
+		func(doc DocumentDoc) string { return doc.Type },
+		func(c string, doc DocumentDoc) SchemaChange {
+			return SchemaChange{
+				Change: c, Kind: "document", Path: doc.Type,
+				DocumentType: doc.Type,
+			}
+		},
+		func(a, b DocumentDoc) []SchemaChange {
+			changes := diffConstraintMap(
+				b.Type, "attribute", "", a.Attributes, b.Attributes)
+
+			changes = append(changes, diffResolvedBlocks(
+				b.Type, "meta", a.Meta, b.Meta)...)
+			changes = append(changes, diffResolvedBlocks(
+				b.Type, "link", a.Links, b.Links)...)
+			changes = append(changes, diffResolvedBlocks(
+				b.Type, "content", a.Content, b.Content)...)
+
+			for i := range changes {
+				changes[i].DocumentType = b.Type
+			}
+
+			return changes
+		})
+
+	d.Blocks = diffByKey(from.Blocks, to.Blocks,
+		func(b BlockDoc) string { return b.Kind + " " + b.ID },
+		func(c string, b BlockDoc) SchemaChange {
+			return SchemaChange{
+				Change: c, Kind: "block", Path: b.Kind + " " + b.ID,
+			}
+		},
+		func(a, b BlockDoc) []SchemaChange {
+			return diffBlockConstraint(b.Kind+" "+b.ID, a.Block, b.Block)
+		})
+
+	d.Enums = diffByKey(from.Enums, to.Enums,
+		func(e EnumDoc) string { return e.ID },
+		func(c string, e EnumDoc) SchemaChange {
+			return SchemaChange{Change: c, Kind: "enum", Path: e.ID}
+		},
+		diffEnumDoc)
+
+	d.Policies = diffByKey(from.Policies, to.Policies,
+		func(p PolicyDoc) string { return p.Name },
+		func(c string, p PolicyDoc) SchemaChange {
+			return SchemaChange{Change: c, Kind: "HTML policy", Path: p.Name}
+		},
+		diffPolicyDoc)
+
+	return &d
+}
+
+// diffByKey matches items by key and reports added and removed items, and the
+// changes to items that exist in both lists.
+func diffByKey[T any](
+	before, after []T,
+	key func(T) string,
+	change func(c string, item T) SchemaChange,
+	diff func(a, b T) []SchemaChange,
+) []SchemaChange {
+	var changes []SchemaChange
+
+	old := make(map[string]T, len(before))
+
+	for _, item := range before {
+		old[key(item)] = item
+	}
+
+	seen := make(map[string]bool, len(after))
+
+	for _, item := range after {
+		k := key(item)
+
+		seen[k] = true
+
+		o, ok := old[k]
+		if !ok {
+			changes = append(changes, change("added", item))
+
+			continue
+		}
+
+		changes = append(changes, diff(o, item)...)
+	}
+
+	for _, item := range before {
+		if !seen[key(item)] {
+			changes = append(changes, change("removed", item))
+		}
+	}
+
+	return changes
+}
+
+func diffResolvedBlocks(
+	path string, kind string, before, after []ResolvedBlock,
+) []SchemaChange {
+	return diffBlockList(path, kind,
+		resolvedBlockConstraints(before), resolvedBlockConstraints(after))
+}
+
+func resolvedBlockConstraints(blocks []ResolvedBlock) []keyedBlock {
+	result := make([]keyedBlock, len(blocks))
+
+	for i, rb := range blocks {
+		result[i] = keyedBlock{
+			Ref:   rb.Ref,
+			Block: rb.Block,
+		}
+	}
+
+	return result
+}
+
+type keyedBlock struct {
+	Ref   string
+	Block revisor.BlockConstraint
+	key   string
+}
+
+// diffBlockList matches blocks by their reference or signature.
+func diffBlockList(
+	path string, kind string, before, after []keyedBlock,
+) []SchemaChange {
+	keyBlocks(kind, before)
+	keyBlocks(kind, after)
+
+	return diffByKey(before, after,
+		func(b keyedBlock) string { return b.key },
+		func(c string, b keyedBlock) SchemaChange {
+			return SchemaChange{
+				Change: c, Kind: "block", Path: path + " > " + b.key,
+			}
+		},
+		func(a, b keyedBlock) []SchemaChange {
+			return diffBlockConstraint(path+" > "+b.key, a.Block, b.Block)
+		})
+}
+
+// keyBlocks sets the keys of a list of blocks, numbering blocks that share a
+// key.
+func keyBlocks(kind string, blocks []keyedBlock) {
+	count := make(map[string]int)
+
+	for i := range blocks {
+		k := kind + " " + blockKey(blocks[i].Ref, blocks[i].Block)
+
+		count[k]++
+
+		if count[k] > 1 {
+			k = fmt.Sprintf("%s #%d", k, count[k])
+		}
+
+		blocks[i].key = k
+	}
+}
+
+// blockKey identifies a block by its reference, declared signature, or match
+// constraints.
+func blockKey(ref string, bc revisor.BlockConstraint) string {
+	if ref != "" {
+		return ref
+	}
+
+	var parts []string
+
+	if bc.Declares != nil {
+		for _, kv := range [][2]string{
+			{"type", bc.Declares.Type},
+			{"rel", bc.Declares.Rel},
+			{"role", bc.Declares.Role},
+		} {
+			if kv[1] != "" {
+				parts = append(parts, kv[0]+"="+kv[1])
+			}
+		}
+	}
+
+	if len(parts) == 0 && len(bc.Match.Keys) > 0 {
+		keys := slices.Sorted(slices.Values(bc.Match.Keys))
+
+		parts = append(parts, "match("+strings.Join(keys, ",")+")")
+	}
+
+	if len(parts) == 0 {
+		return "(any)"
+	}
+
+	return strings.Join(parts, " ")
+}
+
+func diffBlockConstraint(path string, a, b revisor.BlockConstraint) []SchemaChange {
+	var changes []SchemaChange
+
+	oldCount, newCount := countString(a), countString(b)
+	if oldCount != newCount {
+		changes = append(changes, SchemaChange{
+			Change: "changed", Kind: "count", Path: path,
+			Detail: fmt.Sprintf("%s to %s", oldCount, newCount),
+		})
+	}
+
+	if (a.Deprecated == nil) != (b.Deprecated == nil) {
+		detail := "deprecated"
+		if b.Deprecated == nil {
+			detail = "no longer deprecated"
+		}
+
+		changes = append(changes, SchemaChange{
+			Change: "changed", Kind: "block", Path: path, Detail: detail,
+		})
+	}
+
+	changes = append(changes, diffConstraintMap(
+		path, "attribute", "", a.Attributes, b.Attributes)...)
+	changes = append(changes, diffConstraintMap(
+		path, "data", "data.", a.Data, b.Data)...)
+
+	for _, nested := range []struct {
+		kind          string
+		before, after []*revisor.BlockConstraint
+	}{
+		{"meta", a.Meta, b.Meta},
+		{"link", a.Links, b.Links},
+		{"content", a.Content, b.Content},
+	} {
+		changes = append(changes, diffBlockList(path, nested.kind,
+			nestedBlocks(nested.before), nestedBlocks(nested.after))...)
+	}
+
+	return changes
+}
+
+func nestedBlocks(blocks []*revisor.BlockConstraint) []keyedBlock {
+	var result []keyedBlock
+
+	for _, bc := range blocks {
+		if bc == nil {
+			continue
+		}
+
+		result = append(result, keyedBlock{
+			Ref:   bc.Ref,
+			Block: *bc,
+		})
+	}
+
+	return result
+}
+
+func countString(bc revisor.BlockConstraint) string {
+	if bc.Count != nil {
+		return fmt.Sprintf("exactly %d", *bc.Count)
+	}
+
+	var parts []string
+
+	if bc.MinCount != nil {
+		parts = append(parts, fmt.Sprintf("min %d", *bc.MinCount))
+	}
+
+	if bc.MaxCount != nil {
+		parts = append(parts, fmt.Sprintf("max %d", *bc.MaxCount))
+	}
+
+	if len(parts) == 0 {
+		return "any number"
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+func diffConstraintMap(
+	path string, kind string, prefix string, a, b revisor.ConstraintMap,
+) []SchemaChange {
+	var changes []SchemaChange
+
+	for _, k := range b.Keys {
+		name := path + " > " + prefix + k
+
+		old, ok := a.Constraints[k]
+		if !ok {
+			changes = append(changes, SchemaChange{
+				Change: "added", Kind: kind, Path: name,
+				Detail: constraintString(b.Constraints[k]),
+			})
+
+			continue
+		}
+
+		before, after := constraintString(old), constraintString(b.Constraints[k])
+		if before != after {
+			changes = append(changes, SchemaChange{
+				Change: "changed", Kind: kind, Path: name,
+				Detail: fmt.Sprintf("%s to %s", before, after),
+			})
+		}
+	}
+
+	for _, k := range a.Keys {
+		if _, ok := b.Constraints[k]; !ok {
+			changes = append(changes, SchemaChange{
+				Change: "removed", Kind: kind, Path: path + " > " + prefix + k,
+			})
+		}
+	}
+
+	return changes
+}
+
+// constraintString describes the parts of a string constraint that affect
+// validation.
+func constraintString(sc revisor.StringConstraint) string {
+	var parts []string
+
+	if sc.Optional {
+		parts = append(parts, "optional")
+	} else {
+		parts = append(parts, "required")
+	}
+
+	if sc.AllowEmpty {
+		parts = append(parts, "may be empty")
+	}
+
+	if req := sc.Requirement(); req != "" {
+		parts = append(parts, req)
+	}
+
+	if sc.EnumRef != "" {
+		parts = append(parts, "uses enum "+sc.EnumRef)
+	}
+
+	if sc.HTMLPolicy != "" {
+		parts = append(parts, "uses HTML policy "+sc.HTMLPolicy)
+	}
+
+	if sc.Deprecated != nil {
+		parts = append(parts, "deprecated")
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+func diffEnumDoc(a, b EnumDoc) []SchemaChange {
+	// Sets can forbid values that other sets declare, so the same value
+	// can be listed once per set.
+	return diffByKey(a.Values, b.Values,
+		func(v EnumValueDoc) string { return v.Source + " " + v.Value },
+		func(c string, v EnumValueDoc) SchemaChange {
+			change := SchemaChange{
+				Change: c, Kind: "enum value", Path: b.ID + " > " + v.Value,
+			}
+
+			if v.Forbidden {
+				change.Detail = "forbidden by " + v.Source
+			}
+
+			return change
+		},
+		func(x, y EnumValueDoc) []SchemaChange {
+			var changes []SchemaChange
+
+			if x.Forbidden != y.Forbidden {
+				detail := "forbidden"
+				if !y.Forbidden {
+					detail = "allowed"
+				}
+
+				changes = append(changes, SchemaChange{
+					Change: "changed", Kind: "enum value",
+					Path: b.ID + " > " + y.Value, Detail: detail,
+				})
+			}
+
+			if (x.Deprecated == nil) != (y.Deprecated == nil) {
+				detail := "deprecated"
+				if y.Deprecated == nil {
+					detail = "no longer deprecated"
+				}
+
+				changes = append(changes, SchemaChange{
+					Change: "changed", Kind: "enum value",
+					Path: b.ID + " > " + y.Value, Detail: detail,
+				})
+			}
+
+			return changes
+		})
+}
+
+func diffPolicyDoc(a, b PolicyDoc) []SchemaChange {
+	var changes []SchemaChange
+
+	for _, name := range slices.Sorted(maps.Keys(b.Elements)) {
+		path := b.Name + " > " + name
+
+		old, ok := a.Elements[name]
+		if !ok {
+			changes = append(changes, SchemaChange{
+				Change: "added", Kind: "element", Path: path,
+			})
+
+			continue
+		}
+
+		changes = append(changes, diffConstraintMap(
+			path, "element attribute", "",
+			old.Attributes, b.Elements[name].Attributes)...)
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(a.Elements)) {
+		if _, ok := b.Elements[name]; !ok {
+			changes = append(changes, SchemaChange{
+				Change: "removed", Kind: "element", Path: b.Name + " > " + name,
+			})
+		}
+	}
+
+	return changes
+}
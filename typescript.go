@@ -0,0 +1,433 @@
+package elephantdocs
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/ttab/revisor"
+)
+
+// schemaTypeScript generates TypeScript declarations for the declared document
+// types of a schema version. Blocks are discriminated unions keyed on their
+// type, rel and role, and enums are string literal unions.
+func schemaTypeScript(doc *SchemaDoc, version string) string {
+	g := tsGenerator{
+		names: map[string]bool{"Document": true},
+		enums: make(map[string]string),
+	}
+
+	for _, e := range doc.Enums {
+		g.enums[e.ID] = g.name(e.ID)
+	}
+
+	for _, e := range doc.Enums {
+		g.enum(e)
+	}
+
+	var docTypes []string
+
+	for _, d := range doc.Documents {
+		if d.DeclaredIn == "" {
+			continue
+		}
+
+		docTypes = append(docTypes, g.document(d))
+	}
+
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "// NewsDoc types for schema version %s, generated from the\n", version)
+	b.WriteString("// document schemas. Do not edit.\n")
+
+	for _, decl := range g.decls {
+		b.WriteString("\n")
+		b.WriteString(decl)
+	}
+
+	b.WriteString("\n/** Any of the declared document types. */\n")
+
+	if len(docTypes) == 0 {
+		b.WriteString("export type Document = never;\n")
+	} else {
+		fmt.Fprintf(&b, "export type Document =\n  | %s;\n",
+			strings.Join(docTypes, "\n  | "))
+	}
+
+	return b.String()
+}
+
+type tsGenerator struct {
+	names map[string]bool
+	enums map[string]string
+	decls []string
+}
+
+var tsWordSeparators = regexp.MustCompile(`[^A-Za-z0-9]+`)
+
+// name creates a unique type name from the parts of a schema identifier.
+func (g *tsGenerator) name(parts ...string) string {
+	var b strings.Builder
+
+	for _, p := range parts {
+		for _, w := range tsWordSeparators.Split(p, -1) {
+			if w == "" {
+				continue
+			}
+
+			r := []rune(w)
+			r[0] = unicode.ToUpper(r[0])
+
+			b.WriteString(string(r))
+		}
+	}
+
+	base := b.String()
+
+	if base == "" || unicode.IsDigit(rune(base[0])) {
+		base = "T" + base
+	}
+
+	name := base
+
+	for i := 2; g.names[name]; i++ {
+		name = base + strconv.Itoa(i)
+	}
+
+	g.names[name] = true
+
+	return name
+}
+
+func (g *tsGenerator) enum(e EnumDoc) {
+	var (
+		b      strings.Builder
+		values []string
+	)
+
+	for _, v := range e.Values {
+		if !v.Forbidden {
+			values = append(values, strconv.Quote(v.Value))
+		}
+	}
+
+	if len(values) == 0 {
+		values = []string{"never"}
+	}
+
+	tsDoc(&b, "", e.Description, nil)
+	fmt.Fprintf(&b, "export type %s = %s;\n",
+		g.enums[e.ID], strings.Join(values, " | "))
+
+	g.decls = append(g.decls, b.String())
+}
+
+// document declares the interface of a document type and returns its name.
+func (g *tsGenerator) document(d DocumentDoc) string {
+	name := g.name(d.Type)
+
+	// Reserve the position of the document so that it's declared before
+	// its blocks.
+	idx := len(g.decls)
+	g.decls = append(g.decls, "")
+
+	var b strings.Builder
+
+	tsDoc(&b, "", d.Description, d.Deprecated)
+	fmt.Fprintf(&b, "export interface %s {\n", name)
+
+	for _, k := range documentAttributeNames {
+		sc, ok := d.Attributes.Constraints[k]
+
+		switch {
+		case k == "type":
+			fmt.Fprintf(&b, "  type: %s;\n", strconv.Quote(d.Type))
+		case k == "uuid" && ok:
+			// Revisor requires a valid UUID even if the
+			// attribute is allowed to be empty.
+			sc.AllowEmpty = false
+
+			g.property(&b, k, sc, false)
+		case ok:
+			g.property(&b, k, sc, sc.AllowEmpty)
+		case k == "uuid":
+			b.WriteString("  uuid: string;\n")
+		default:
+			fmt.Fprintf(&b, "  %s?: string;\n", k)
+		}
+	}
+
+	g.blockLists(&b, name,
+		resolvedConstraints(d.Meta),
+		resolvedConstraints(d.Links),
+		resolvedConstraints(d.Content))
+
+	b.WriteString("}\n")
+
+	g.decls[idx] = b.String()
+
+	return name
+}
+
+// blockLists adds the block list properties of a document or block, with
+// a union type for each kind of block.
+func (g *tsGenerator) blockLists(
+	b *strings.Builder, parent string,
+	meta, links, content []revisor.BlockConstraint,
+) {
+	lists := []struct {
+		property    string
+		kind        string
+		constraints []revisor.BlockConstraint
+	}{
+		{"meta", "Meta", meta},
+		{"links", "Link", links},
+		{"content", "Content", content},
+	}
+
+	for _, l := range lists {
+		union := g.blockUnion(parent+l.kind, l.constraints)
+		if union == "" {
+			continue
+		}
+
+		fmt.Fprintf(b, "  %s?: %s[];\n", l.property, union)
+	}
+}
+
+// blockUnion declares the blocks of a list and a union of them, and returns
+// the name of the union, or an empty string if no blocks are declared.
+func (g *tsGenerator) blockUnion(
+	prefix string, constraints []revisor.BlockConstraint,
+) string {
+	extensions := blockExtensions(constraints)
+
+	var declarations []revisor.BlockConstraint
+
+	for _, bc := range constraints {
+		if bc.Declares != nil {
+			declarations = append(declarations, bc)
+		}
+	}
+
+	if len(declarations) == 0 {
+		return ""
+	}
+
+	union := g.name(prefix)
+
+	idx := len(g.decls)
+	g.decls = append(g.decls, "")
+
+	members := make([]string, len(declarations))
+
+	for i, bc := range declarations {
+		members[i] = g.block(prefix, bc, extensions)
+	}
+
+	g.decls[idx] = fmt.Sprintf("export type %s =\n  | %s;\n",
+		union, strings.Join(members, "\n  | "))
+
+	return union
+}
+
+func (g *tsGenerator) block(
+	prefix string, bc revisor.BlockConstraint,
+	extensions []revisor.BlockConstraint,
+) string {
+	name := g.name(prefix, bc.Declares.Type, bc.Declares.Rel, bc.Declares.Role)
+	eb := extendBlock(bc, extensions)
+
+	idx := len(g.decls)
+	g.decls = append(g.decls, "")
+
+	description := bc.Description
+	if description == "" {
+		description = bc.Name
+	}
+
+	var b strings.Builder
+
+	tsDoc(&b, "", description, bc.Deprecated)
+	fmt.Fprintf(&b, "export interface %s {\n", name)
+
+	if _, ok := eb.Attributes.Constraints["id"]; !ok {
+		b.WriteString("  id?: string;\n")
+	}
+
+	for _, k := range eb.Attributes.Keys {
+		sc := eb.Attributes.Constraints[k]
+
+		g.property(&b, k, sc, sc.AllowEmpty)
+	}
+
+	// Extensions that only apply to some blocks can add attributes and
+	// data.
+	var conditional, conditionalData []string
+
+	for _, ext := range eb.Conditional {
+		conditional = append(conditional, ext.Attributes.Keys...)
+		conditionalData = append(conditionalData, ext.Data.Keys...)
+	}
+
+	for i, k := range conditional {
+		_, ok := eb.Attributes.Constraints[k]
+		if !ok && !slices.Contains(conditional[:i], k) {
+			fmt.Fprintf(&b, "  %s?: string;\n", tsProperty(k))
+		}
+	}
+
+	g.data(&b, eb.Data, conditionalData)
+
+	g.blockLists(&b, name,
+		nestedConstraints(bc.Meta),
+		nestedConstraints(bc.Links),
+		nestedConstraints(bc.Content))
+
+	b.WriteString("}\n")
+
+	g.decls[idx] = b.String()
+
+	return name
+}
+
+func (g *tsGenerator) data(
+	b *strings.Builder, data revisor.ConstraintMap, conditional []string,
+) {
+	var (
+		extra    []string
+		required bool
+	)
+
+	for _, k := range conditional {
+		_, ok := data.Constraints[k]
+		if !ok && !slices.Contains(extra, k) {
+			extra = append(extra, k)
+		}
+	}
+
+	if len(data.Keys) == 0 && len(extra) == 0 {
+		return
+	}
+
+	var fields strings.Builder
+
+	for _, k := range data.Keys {
+		sc := data.Constraints[k]
+
+		required = required || !sc.Optional
+
+		g.property(&fields, k, sc, sc.Optional)
+	}
+
+	for _, k := range extra {
+		fmt.Fprintf(&fields, "  %s?: string;\n", tsProperty(k))
+	}
+
+	optional := "?"
+	if required {
+		optional = ""
+	}
+
+	fmt.Fprintf(b, "  data%s: {\n", optional)
+
+	for line := range strings.Lines(fields.String()) {
+		b.WriteString("  " + line)
+	}
+
+	b.WriteString("  };\n")
+}
+
+// property adds a string property with its documentation.
+func (g *tsGenerator) property(
+	b *strings.Builder, key string, sc revisor.StringConstraint, optional bool,
+) {
+	description := sc.Description
+	if description == "" {
+		description = sc.Name
+	}
+
+	tsDoc(b, "  ", description, sc.Deprecated)
+
+	marker := ""
+	if optional {
+		marker = "?"
+	}
+
+	fmt.Fprintf(b, "  %s%s: %s;\n", tsProperty(key), marker, g.valueType(sc))
+}
+
+func (g *tsGenerator) valueType(sc revisor.StringConstraint) string {
+	var values []string
+
+	switch {
+	case sc.Const != nil:
+		values = []string{strconv.Quote(*sc.Const)}
+	case len(sc.Enum) > 0:
+		for _, v := range sc.Enum {
+			values = append(values, strconv.Quote(v))
+		}
+	case g.enums[sc.EnumRef] != "":
+		values = []string{g.enums[sc.EnumRef]}
+	default:
+		return "string"
+	}
+
+	if sc.AllowEmpty && !slices.Contains(values, `""`) {
+		values = append(values, `""`)
+	}
+
+	return strings.Join(values, " | ")
+}
+
+var tsIdentifier = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+// tsProperty quotes property names that aren't valid identifiers.
+func tsProperty(key string) string {
+	if tsIdentifier.MatchString(key) {
+		return key
+	}
+
+	return strconv.Quote(key)
+}
+
+// tsDoc writes a JSDoc comment with the description and deprecation of a
+// declaration.
+func tsDoc(
+	b *strings.Builder, indent string, description string,
+	deprecated *revisor.Deprecation,
+) {
+	var lines []string
+
+	for line := range strings.Lines(strings.TrimSpace(description)) {
+		lines = append(lines, strings.TrimRight(line, "\n"))
+	}
+
+	if deprecated != nil {
+		lines = append(lines, strings.TrimSpace("@deprecated "+deprecated.Doc))
+	}
+
+	for i := range lines {
+		lines[i] = strings.ReplaceAll(lines[i], "*/", `*\/`)
+	}
+
+	switch len(lines) {
+	case 0:
+		return
+	case 1:
+		fmt.Fprintf(b, "%s/** %s */\n", indent, lines[0])
+
+		return
+	}
+
+	fmt.Fprintf(b, "%s/**\n", indent)
+
+	for _, line := range lines {
+		fmt.Fprintf(b, "%s%s\n", indent, strings.TrimRight(" * "+line, " "))
+	}
+
+	fmt.Fprintf(b, "%s */\n", indent)
+}
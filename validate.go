@@ -0,0 +1,198 @@
+package elephantdocs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+
+	"github.com/ttab/elephant-docs/internal/schemalink"
+	"github.com/ttab/newsdoc"
+	"github.com/ttab/revisor"
+)
+
+// DocumentValidator validates NewsDoc documents against the constraint sets
+// of a schema version, and links the violations to the schema documentation.
+type DocumentValidator struct {
+	// Version is the schema version tag, or "local" for a local checkout.
+	Version string
+
+	validator *revisor.Validator
+	links     schemalink.Index
+	root      string
+}
+
+// ValidationProblem is a validation error with a link to the documentation of
+// the document type, block or enum that it concerns.
+type ValidationProblem struct {
+	Entity []revisor.EntityRef `json:"entity,omitempty"`
+	Error  string              `json:"error"`
+	Link   string              `json:"link,omitempty"`
+}
+
+func (p ValidationProblem) String() string {
+	return revisor.ValidationResult{
+		Entity: p.Entity,
+		Error:  p.Error,
+	}.String()
+}
+
+// NewDocumentValidator loads the constraint sets of a schema version. The
+// latest version of the release channel is used if tag is empty. If dir is set
+// the constraint sets are read from a local checkout instead, and the links
+// point to the documentation of the latest version.
+func NewDocumentValidator(conf Config, tag string, dir string) (*DocumentValidator, error) {
+	if conf.Schemas == nil {
+		return nil, errors.New("no schemas have been configured")
+	}
+
+	schemas := *conf.Schemas
+
+	v := DocumentValidator{
+		Version: "local",
+		root:    "/schemas",
+	}
+
+	var sets []revisor.ConstraintSet
+
+	switch {
+	case dir != "" && tag != "":
+		return nil, errors.New("a version can't be used with a local checkout")
+	case dir != "":
+		local, err := loadLocalConstraintSets(dir, schemas)
+		if err != nil {
+			return nil, fmt.Errorf("load constraint sets: %w", err)
+		}
+
+		sets = local
+	default:
+		_, versions, latest, err := cloneSchemaRepo(schemas)
+		if err != nil {
+			return nil, fmt.Errorf("list schema versions: %w", err)
+		}
+
+		version := latest
+
+		if tag != "" && tag != latest.Tag {
+			idx := slices.IndexFunc(versions, func(v *ModuleVersion) bool {
+				return v.Tag == tag
+			})
+			if idx == -1 {
+				return nil, fmt.Errorf("unknown schema version %q", tag)
+			}
+
+			version = versions[idx]
+			v.root = "/schemas/" + tag
+		}
+
+		v.Version = version.Tag
+
+		sets, err = loadConstraintSets(version.Commit, schemas)
+		if err != nil {
+			return nil, fmt.Errorf("load constraint sets: %w", err)
+		}
+	}
+
+	doc, validator, err := resolveSchemaSource(schemas, sets)
+	if err != nil {
+		return nil, err
+	}
+
+	if conf.SiteURL != "" {
+		site, err := url.Parse(conf.SiteURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid site URL: %w", err)
+		}
+
+		v.root = site.JoinPath(v.root).String()
+	}
+
+	v.links = newLinkIndex(doc, v.root)
+	v.validator = validator
+
+	return &v, nil
+}
+
+// Validate validates a document and returns the problems that were found.
+func (v *DocumentValidator) Validate(
+	ctx context.Context, doc *newsdoc.Document,
+) ([]ValidationProblem, error) {
+	results, err := v.validator.ValidateDocument(ctx, doc)
+	if err != nil {
+		return nil, fmt.Errorf("validate document: %w", err)
+	}
+
+	problems := make([]ValidationProblem, len(results))
+
+	for i, r := range results {
+		problems[i] = ValidationProblem{
+			Entity: r.Entity,
+			Error:  r.Error,
+			Link:   v.links.Link(doc, r.Entity),
+		}
+	}
+
+	return problems, nil
+}
+
+// schemaBlockHRef links to the page of a block definition, or to the block on
+// the document type page if it isn't a reference.
+func schemaBlockHRef(root string, docType string, rb ResolvedBlock) string {
+	if rb.Ref != "" {
+		return root + "/blocks/" + rb.BlockKind + "/" + SchemaSlug(rb.Ref) + "/"
+	}
+
+	return root + "/documents/" + docType + "/#block-" + blockAnchor(rb.Block)
+}
+
+func schemaEnumHRef(root string, id string) string {
+	return root + "/enums/" + SchemaSlug(id) + "/"
+}
+
+// newLinkIndex indexes the pages that document the document types, blocks and
+// enums of a schema version.
+func newLinkIndex(doc *SchemaDoc, root string) schemalink.Index {
+	index := schemalink.Index{
+		Overview:  root + "/",
+		Documents: make(map[string]schemalink.Document),
+		Enums:     make(map[string]string, len(doc.Enums)),
+	}
+
+	for _, e := range doc.Enums {
+		index.Enums[e.ID] = schemaEnumHRef(root, e.ID)
+	}
+
+	blocks := func(docType string, rbs []ResolvedBlock) []schemalink.Block {
+		var result []schemalink.Block
+
+		for _, rb := range rbs {
+			if rb.Block.Declares == nil {
+				continue
+			}
+
+			result = append(result, schemalink.Block{
+				HRef:       schemaBlockHRef(root, docType, rb),
+				Constraint: rb.Block,
+			})
+		}
+
+		return result
+	}
+
+	for _, d := range doc.Documents {
+		if d.DeclaredIn == "" {
+			continue
+		}
+
+		index.Documents[d.Type] = schemalink.Document{
+			HRef:       root + "/documents/" + d.Type + "/",
+			Attributes: d.Attributes,
+			Meta:       blocks(d.Type, d.Meta),
+			Links:      blocks(d.Type, d.Links),
+			Content:    blocks(d.Type, d.Content),
+		}
+	}
+
+	return index
+}
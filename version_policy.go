@@ -0,0 +1,153 @@
+package elephantdocs
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// applyVersionPolicy filters a list of versions sorted in descending order.
+// Returns the versions unchanged if policy is nil.
+func applyVersionPolicy(
+	versions []*ModuleVersion, policy *VersionPolicy,
+) ([]*ModuleVersion, error) {
+	if policy == nil {
+		return versions, nil
+	}
+
+	var minVersion *semver.Version
+
+	if policy.Min != "" {
+		v, err := semver.NewVersion(policy.Min)
+		if err != nil {
+			return nil, fmt.Errorf("invalid min version %q: %w",
+				policy.Min, err)
+		}
+
+		minVersion = v
+	}
+
+	constraints := make([]*semver.Constraints, len(policy.Constraints))
+
+	for i, c := range policy.Constraints {
+		sc, err := semver.NewConstraint(c)
+		if err != nil {
+			return nil, fmt.Errorf("invalid version constraint %q: %w",
+				c, err)
+		}
+
+		constraints[i] = sc
+	}
+
+	result := make([]*ModuleVersion, 0, len(versions))
+
+	for _, v := range versions {
+		if slices.Contains(policy.Exclude, v.Tag) {
+			continue
+		}
+
+		if minVersion != nil && v.Version.LessThan(minVersion) {
+			continue
+		}
+
+		if len(constraints) > 0 && !matchesAnyConstraint(v.Version, constraints) {
+			continue
+		}
+
+		result = append(result, v)
+	}
+
+	if policy.ExcludeOldPrereleases {
+		result = excludeOldPrereleases(result)
+	}
+
+	if policy.LatestPatchOnly {
+		result = latestPatchOnly(result)
+	}
+
+	return result, nil
+}
+
+func matchesAnyConstraint(v *semver.Version, constraints []*semver.Constraints) bool {
+	// Constraints never match pre-releases unless they contain a
+	// pre-release themselves, check against the release version instead.
+	if v.Prerelease() != "" {
+		release, err := v.SetPrerelease("")
+		if err == nil {
+			v = &release
+		}
+	}
+
+	for _, c := range constraints {
+		if c.Check(v) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// excludeOldPrereleases drops all pre-releases that are older than the newest
+// stable version.
+func excludeOldPrereleases(versions []*ModuleVersion) []*ModuleVersion {
+	var latestStable *semver.Version
+
+	for _, v := range versions {
+		if !v.IsPrerelease {
+			latestStable = v.Version
+
+			break
+		}
+	}
+
+	if latestStable == nil {
+		return versions
+	}
+
+	return slices.DeleteFunc(versions, func(v *ModuleVersion) bool {
+		return v.IsPrerelease && v.Version.LessThan(latestStable)
+	})
+}
+
+// latestPatchOnly keeps the newest stable version of each minor version, and
+// the newest pre-release of the minor version if it's newer than the stable
+// version.
+func latestPatchOnly(versions []*ModuleVersion) []*ModuleVersion {
+	type minorState struct {
+		stable     bool
+		prerelease bool
+	}
+
+	seen := make(map[string]*minorState)
+
+	return slices.DeleteFunc(versions, func(v *ModuleVersion) bool {
+		minor := fmt.Sprintf("%d.%d", v.Version.Major(), v.Version.Minor())
+
+		state, ok := seen[minor]
+		if !ok {
+			state = &minorState{}
+			seen[minor] = state
+		}
+
+		// Versions are sorted in descending order, so pre-releases that
+		// come after the stable version are older than it.
+		if v.IsPrerelease {
+			if state.stable || state.prerelease {
+				return true
+			}
+
+			state.prerelease = true
+
+			return false
+		}
+
+		if state.stable {
+			return true
+		}
+
+		state.stable = true
+
+		return false
+	})
+}